@@ -0,0 +1,55 @@
+package corelib
+
+import (
+	"errors"
+	"fmt"
+	"math"
+)
+
+// Checks that a method got between `min` and `max` arguments, not counting the
+// value it was called on. A `max` of -1 means there is no upper limit
+func expectArgs(method string, args []interface{}, min int, max int) error {
+	count := len(args) - 1
+	if count >= min && (max == -1 || count <= max) {
+		return nil
+	}
+
+	// Worded like the arity errors of functions with params
+	var expected string
+	switch {
+	case min == max && min == 0:
+		expected = "no arguments"
+	case min == max && min == 1:
+		expected = "1 argument"
+	case min == max:
+		expected = fmt.Sprintf("%d arguments", min)
+	case max == -1 && min == 1:
+		expected = "at least 1 argument"
+	case max == -1:
+		expected = fmt.Sprintf("at least %d arguments", min)
+	default:
+		expected = fmt.Sprintf("%d to %d arguments", min, max)
+	}
+	return errors.New(fmt.Sprintf("`%s` expects %s, but got %d", method, expected, count))
+}
+
+// The string argument at index `i`
+func stringArg(method string, args []interface{}, i int) (string, error) {
+	str, ok := args[i].(string)
+	if !ok {
+		return "", errors.New(fmt.Sprintf("`%s` expects a string, but got %v", method, args[i]))
+	}
+	return str, nil
+}
+
+// The integer argument at index `i`
+func intArg(method string, args []interface{}, i int) (int, error) {
+	n, ok := args[i].(int64)
+	if !ok {
+		return 0, errors.New(fmt.Sprintf("`%s` expects an integer, but got %v", method, args[i]))
+	}
+	if n < math.MinInt || n > math.MaxInt {
+		return 0, errors.New(fmt.Sprintf("`%s` got %d, which is out of range", method, n))
+	}
+	return int(n), nil
+}
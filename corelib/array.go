@@ -0,0 +1,530 @@
+package corelib
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+
+	"github.com/jomy10/nootlang/runtime"
+)
+
+// `push`, `pop`, `insert` and `remove` change the array they are called on. All
+// other methods return a new array
+func registerArrayMethods(r *runtime.Runtime) {
+	array_type := reflect.TypeOf([]interface{}{})
+	r.Methods[array_type] = make(map[string]runtime.NativeFunction)
+
+	r.Methods[array_type]["len"] = array__len
+	r.Methods[array_type]["join"] = array__join
+	r.Methods[array_type]["push"] = array__push
+	r.Methods[array_type]["pop"] = array__pop
+	r.Methods[array_type]["insert"] = array__insert
+	r.Methods[array_type]["remove"] = array__remove
+	r.Methods[array_type]["slice"] = array__slice
+	r.Methods[array_type]["reverse"] = array__reverse
+	r.Methods[array_type]["sort"] = array__sort
+	r.Methods[array_type]["contains"] = array__contains
+	r.Methods[array_type]["index_of"] = array__index_of
+	r.Methods[array_type]["map"] = array__map
+	r.Methods[array_type]["filter"] = array__filter
+	r.Methods[array_type]["reduce"] = array__reduce
+	r.Methods[array_type]["any"] = array__any
+	r.Methods[array_type]["all"] = array__all
+	r.Methods[array_type]["zip"] = array__zip
+	r.Methods[array_type]["enumerate"] = array__enumerate
+	r.Methods[array_type]["flatten"] = array__flatten
+	r.Methods[array_type]["unique"] = array__unique
+	r.Methods[array_type]["min"] = array__min
+	r.Methods[array_type]["max"] = array__max
+	r.Methods[array_type]["sum"] = array__sum
+}
+
+func array__len(runtime *runtime.Runtime, args []interface{}) (interface{}, error) {
+	if err := expectArgs("array.len", args, 0, 0); err != nil {
+		return nil, err
+	}
+
+	lhs, ok := args[0].([]interface{})
+	if !ok {
+		return nil, errors.New("intepreter error in `arrray.len`")
+	}
+
+	return int64(len(lhs)), nil
+}
+
+// array.push(values...) appends the values to the array
+func array__push(_ *runtime.Runtime, args []interface{}) (interface{}, error) {
+	if err := expectArgs("array.push", args, 1, -1); err != nil {
+		return nil, err
+	}
+	// Appended in place, arrays read from the variable don't have its spare
+	// capacity, so they don't see the new elements
+	arr := args[0].([]interface{})
+	for _, value := range args[1:] {
+		arr = append(arr, value)
+	}
+	return runtime.MutatedReceiver{Receiver: arr}, nil
+}
+
+// array.pop() removes the last element and returns it
+func array__pop(_ *runtime.Runtime, args []interface{}) (interface{}, error) {
+	if err := expectArgs("array.pop", args, 0, 0); err != nil {
+		return nil, err
+	}
+	arr := args[0].([]interface{})
+	if len(arr) == 0 {
+		return nil, errors.New("`array.pop` called on an empty array")
+	}
+	// The capacity of the popped element isn't kept, another array read from
+	// the same variable before may still contain it
+	last := len(arr) - 1
+	return runtime.MutatedReceiver{Receiver: arr[:last:last], Result: arr[last]}, nil
+}
+
+// array.insert(index, value) inserts the value before `index`
+func array__insert(_ *runtime.Runtime, args []interface{}) (interface{}, error) {
+	if err := expectArgs("array.insert", args, 2, 2); err != nil {
+		return nil, err
+	}
+	arr := args[0].([]interface{})
+	index, err := intArg("array.insert", args, 1)
+	if err != nil {
+		return nil, err
+	}
+	if index < 0 || index > len(arr) {
+		return nil, errors.New(fmt.Sprintf("`array.insert` index %d is out of bounds for an array of length %d", index, len(arr)))
+	}
+
+	inserted := make([]interface{}, 0, len(arr)+1)
+	inserted = append(inserted, arr[:index]...)
+	inserted = append(inserted, args[2])
+	inserted = append(inserted, arr[index:]...)
+	return runtime.MutatedReceiver{Receiver: inserted}, nil
+}
+
+// array.remove(index) removes the element at `index` and returns it
+func array__remove(_ *runtime.Runtime, args []interface{}) (interface{}, error) {
+	if err := expectArgs("array.remove", args, 1, 1); err != nil {
+		return nil, err
+	}
+	arr := args[0].([]interface{})
+	index, err := intArg("array.remove", args, 1)
+	if err != nil {
+		return nil, err
+	}
+	if index < 0 || index >= len(arr) {
+		return nil, errors.New(fmt.Sprintf("`array.remove` index %d is out of bounds for an array of length %d", index, len(arr)))
+	}
+
+	removed := make([]interface{}, 0, len(arr)-1)
+	removed = append(removed, arr[:index]...)
+	removed = append(removed, arr[index+1:]...)
+	return runtime.MutatedReceiver{Receiver: removed, Result: arr[index]}, nil
+}
+
+// array.slice(start, end?) returns the elements from `start` up to (but not
+// including) `end`, or up to the end of the array
+func array__slice(_ *runtime.Runtime, args []interface{}) (interface{}, error) {
+	if err := expectArgs("array.slice", args, 1, 2); err != nil {
+		return nil, err
+	}
+	arr := args[0].([]interface{})
+	start, err := intArg("array.slice", args, 1)
+	if err != nil {
+		return nil, err
+	}
+	end := len(arr)
+	if len(args) == 3 {
+		if end, err = intArg("array.slice", args, 2); err != nil {
+			return nil, err
+		}
+	}
+	if start < 0 || end > len(arr) || start > end {
+		return nil, errors.New(fmt.Sprintf("`array.slice` range %d..%d is out of bounds for an array of length %d", start, end, len(arr)))
+	}
+
+	return append([]interface{}{}, arr[start:end]...), nil
+}
+
+// array.reverse()
+func array__reverse(_ *runtime.Runtime, args []interface{}) (interface{}, error) {
+	if err := expectArgs("array.reverse", args, 0, 0); err != nil {
+		return nil, err
+	}
+	arr := args[0].([]interface{})
+	reversed := make([]interface{}, len(arr))
+	for i, element := range arr {
+		reversed[len(arr)-1-i] = element
+	}
+	return reversed, nil
+}
+
+// array.sort(less?) sorts numbers or strings in ascending order. With `less`,
+// `less(a, b)` should return whether `a` comes before `b`
+func array__sort(r *runtime.Runtime, args []interface{}) (interface{}, error) {
+	if err := expectArgs("array.sort", args, 0, 1); err != nil {
+		return nil, err
+	}
+	sorted := append([]interface{}{}, args[0].([]interface{})...)
+
+	less := func(a interface{}, b interface{}) (bool, error) {
+		order, err := compareValues("array.sort", a, b)
+		return order < 0, err
+	}
+	if len(args) == 2 {
+		fn, err := callbackArg("array.sort", args, 1)
+		if err != nil {
+			return nil, err
+		}
+		less = func(a interface{}, b interface{}) (bool, error) {
+			return callPredicate("array.sort", r, fn, a, b)
+		}
+	}
+
+	var err error
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if err != nil {
+			return false
+		}
+		var isLess bool
+		isLess, err = less(sorted[i], sorted[j])
+		return isLess
+	})
+	if err != nil {
+		return nil, err
+	}
+	return sorted, nil
+}
+
+// array.contains(value)
+func array__contains(_ *runtime.Runtime, args []interface{}) (interface{}, error) {
+	if err := expectArgs("array.contains", args, 1, 1); err != nil {
+		return nil, err
+	}
+	return indexOf(args[0].([]interface{}), args[1]) != -1, nil
+}
+
+// array.index_of(value) returns the index of the first element equal to
+// `value`, or -1 if there is none
+func array__index_of(_ *runtime.Runtime, args []interface{}) (interface{}, error) {
+	if err := expectArgs("array.index_of", args, 1, 1); err != nil {
+		return nil, err
+	}
+	return int64(indexOf(args[0].([]interface{}), args[1])), nil
+}
+
+// array.map(fn) returns an array with `fn(element)` for every element
+func array__map(r *runtime.Runtime, args []interface{}) (interface{}, error) {
+	if err := expectArgs("array.map", args, 1, 1); err != nil {
+		return nil, err
+	}
+	fn, err := callbackArg("array.map", args, 1)
+	if err != nil {
+		return nil, err
+	}
+
+	arr := args[0].([]interface{})
+	mapped := make([]interface{}, len(arr))
+	for i, element := range arr {
+		if mapped[i], err = fn(r, []interface{}{element}); err != nil {
+			return nil, err
+		}
+	}
+	return mapped, nil
+}
+
+// array.filter(fn) returns the elements for which `fn(element)` is true
+func array__filter(r *runtime.Runtime, args []interface{}) (interface{}, error) {
+	if err := expectArgs("array.filter", args, 1, 1); err != nil {
+		return nil, err
+	}
+	fn, err := callbackArg("array.filter", args, 1)
+	if err != nil {
+		return nil, err
+	}
+
+	filtered := []interface{}{}
+	for _, element := range args[0].([]interface{}) {
+		keep, err := callPredicate("array.filter", r, fn, element)
+		if err != nil {
+			return nil, err
+		}
+		if keep {
+			filtered = append(filtered, element)
+		}
+	}
+	return filtered, nil
+}
+
+// array.reduce(fn, initial?) combines the elements from left to right with
+// `fn(accumulator, element)`. Without an initial value the first element is
+// used
+func array__reduce(r *runtime.Runtime, args []interface{}) (interface{}, error) {
+	if err := expectArgs("array.reduce", args, 1, 2); err != nil {
+		return nil, err
+	}
+	fn, err := callbackArg("array.reduce", args, 1)
+	if err != nil {
+		return nil, err
+	}
+
+	arr := args[0].([]interface{})
+	var accumulator interface{}
+	if len(args) == 3 {
+		accumulator = args[2]
+	} else if len(arr) == 0 {
+		return nil, errors.New("`array.reduce` called on an empty array without an initial value")
+	} else {
+		accumulator, arr = arr[0], arr[1:]
+	}
+
+	for _, element := range arr {
+		if accumulator, err = fn(r, []interface{}{accumulator, element}); err != nil {
+			return nil, err
+		}
+	}
+	return accumulator, nil
+}
+
+// array.any(fn) returns whether `fn(element)` is true for at least one element
+func array__any(r *runtime.Runtime, args []interface{}) (interface{}, error) {
+	return anyOrAll("array.any", r, args, true)
+}
+
+// array.all(fn) returns whether `fn(element)` is true for every element
+func array__all(r *runtime.Runtime, args []interface{}) (interface{}, error) {
+	return anyOrAll("array.all", r, args, false)
+}
+
+// Stops at the first element for which the predicate returns `stopAt`
+func anyOrAll(method string, r *runtime.Runtime, args []interface{}, stopAt bool) (interface{}, error) {
+	if err := expectArgs(method, args, 1, 1); err != nil {
+		return nil, err
+	}
+	fn, err := callbackArg(method, args, 1)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, element := range args[0].([]interface{}) {
+		result, err := callPredicate(method, r, fn, element)
+		if err != nil {
+			return nil, err
+		}
+		if result == stopAt {
+			return stopAt, nil
+		}
+	}
+	return !stopAt, nil
+}
+
+// array.zip(other) returns an array of `[a, b]` pairs. It is as long as the
+// shortest of the two arrays
+func array__zip(_ *runtime.Runtime, args []interface{}) (interface{}, error) {
+	if err := expectArgs("array.zip", args, 1, 1); err != nil {
+		return nil, err
+	}
+	arr := args[0].([]interface{})
+	other, ok := args[1].([]interface{})
+	if !ok {
+		return nil, errors.New(fmt.Sprintf("`array.zip` expects an array, but got %v", args[1]))
+	}
+
+	length := len(arr)
+	if len(other) < length {
+		length = len(other)
+	}
+	zipped := make([]interface{}, length)
+	for i := 0; i < length; i++ {
+		zipped[i] = []interface{}{arr[i], other[i]}
+	}
+	return zipped, nil
+}
+
+// array.enumerate() returns an array of `[index, element]` pairs
+func array__enumerate(_ *runtime.Runtime, args []interface{}) (interface{}, error) {
+	if err := expectArgs("array.enumerate", args, 0, 0); err != nil {
+		return nil, err
+	}
+	arr := args[0].([]interface{})
+	enumerated := make([]interface{}, len(arr))
+	for i, element := range arr {
+		enumerated[i] = []interface{}{int64(i), element}
+	}
+	return enumerated, nil
+}
+
+// array.flatten() replaces arrays in the array by their elements (one level
+// deep)
+func array__flatten(_ *runtime.Runtime, args []interface{}) (interface{}, error) {
+	if err := expectArgs("array.flatten", args, 0, 0); err != nil {
+		return nil, err
+	}
+	flattened := []interface{}{}
+	for _, element := range args[0].([]interface{}) {
+		if inner, isArray := element.([]interface{}); isArray {
+			flattened = append(flattened, inner...)
+		} else {
+			flattened = append(flattened, element)
+		}
+	}
+	return flattened, nil
+}
+
+// array.unique() removes duplicate elements, keeping the first occurrence
+func array__unique(_ *runtime.Runtime, args []interface{}) (interface{}, error) {
+	if err := expectArgs("array.unique", args, 0, 0); err != nil {
+		return nil, err
+	}
+	unique := []interface{}{}
+	for _, element := range args[0].([]interface{}) {
+		if indexOf(unique, element) == -1 {
+			unique = append(unique, element)
+		}
+	}
+	return unique, nil
+}
+
+// array.min() returns the smallest number or string
+func array__min(_ *runtime.Runtime, args []interface{}) (interface{}, error) {
+	return extreme("array.min", args, -1)
+}
+
+// array.max() returns the largest number or string
+func array__max(_ *runtime.Runtime, args []interface{}) (interface{}, error) {
+	return extreme("array.max", args, 1)
+}
+
+// Returns the element that compares as `order` to all other elements
+func extreme(method string, args []interface{}, order int) (interface{}, error) {
+	if err := expectArgs(method, args, 0, 0); err != nil {
+		return nil, err
+	}
+	arr := args[0].([]interface{})
+	if len(arr) == 0 {
+		return nil, errors.New(fmt.Sprintf("`%s` called on an empty array", method))
+	}
+
+	result := arr[0]
+	for _, element := range arr[1:] {
+		cmp, err := compareValues(method, element, result)
+		if err != nil {
+			return nil, err
+		}
+		if cmp == order {
+			result = element
+		}
+	}
+	return result, nil
+}
+
+// array.sum() adds all numbers. The result is an integer if all elements are
+// integers, and a float otherwise
+func array__sum(_ *runtime.Runtime, args []interface{}) (interface{}, error) {
+	if err := expectArgs("array.sum", args, 0, 0); err != nil {
+		return nil, err
+	}
+
+	var intSum int64
+	var floatSum float64
+	isFloat := false
+	for _, element := range args[0].([]interface{}) {
+		switch element.(type) {
+		case int64:
+			intSum += element.(int64)
+		case float64:
+			floatSum += element.(float64)
+			isFloat = true
+		default:
+			return nil, errors.New(fmt.Sprintf("`array.sum` can only add numbers, but got %v", element))
+		}
+	}
+
+	if isFloat {
+		return floatSum + float64(intSum), nil
+	}
+	return intSum, nil
+}
+
+// The function argument at index `i`, which is either a function declared in
+// noot or a native function
+func callbackArg(method string, args []interface{}, i int) (runtime.NativeFunction, error) {
+	switch args[i].(type) {
+	case *runtime.Function:
+		return args[i].(*runtime.Function).Call, nil
+	case runtime.NativeFunction:
+		return args[i].(runtime.NativeFunction), nil
+	default:
+		return nil, errors.New(fmt.Sprintf("`%s` expects a function, but got %v", method, args[i]))
+	}
+}
+
+// Calls a function that should return a boolean
+func callPredicate(method string, r *runtime.Runtime, fn runtime.NativeFunction, args ...interface{}) (bool, error) {
+	result, err := fn(r, args)
+	if err != nil {
+		return false, err
+	}
+	boolean, ok := result.(bool)
+	if !ok {
+		return false, errors.New(fmt.Sprintf("The function passed to `%s` should return a boolean, but returned %v", method, result))
+	}
+	return boolean, nil
+}
+
+// Elements are equal if they have the same type and value. Arrays are compared
+// element by element
+func indexOf(arr []interface{}, value interface{}) int {
+	for i, element := range arr {
+		if reflect.DeepEqual(element, value) {
+			return i
+		}
+	}
+	return -1
+}
+
+// Returns -1, 0 or 1 when `a` is smaller than, equal to or larger than `b`.
+// Integers and floats can be compared with each other, strings only with
+// strings
+func compareValues(method string, a interface{}, b interface{}) (int, error) {
+	if aInt, isInt := a.(int64); isInt {
+		if bInt, isInt := b.(int64); isInt {
+			return compare(aInt, bInt), nil
+		}
+	}
+	if aStr, isStr := a.(string); isStr {
+		if bStr, isStr := b.(string); isStr {
+			return compare(aStr, bStr), nil
+		}
+	}
+
+	aNum, aIsNum := toFloat(a)
+	bNum, bIsNum := toFloat(b)
+	if !aIsNum || !bIsNum {
+		return 0, errors.New(fmt.Sprintf("`%s` cannot compare %v and %v", method, a, b))
+	}
+	return compare(aNum, bNum), nil
+}
+
+func compare[T int64 | float64 | string](a T, b T) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	default:
+		return 0
+	}
+}
+
+func toFloat(value interface{}) (float64, bool) {
+	switch value.(type) {
+	case int64:
+		return float64(value.(int64)), true
+	case float64:
+		return value.(float64), true
+	default:
+		return 0, false
+	}
+}
@@ -0,0 +1,72 @@
+package corelib
+
+import (
+	"reflect"
+	"sort"
+
+	"github.com/jomy10/nootlang/runtime"
+)
+
+// Maps have string keys. Methods that return keys or values return them in
+// the order of the sorted keys
+func registerMapMethods(r *runtime.Runtime) {
+	map_type := reflect.TypeOf(map[string]interface{}{})
+	r.Methods[map_type] = make(map[string]runtime.NativeFunction)
+
+	r.Methods[map_type]["len"] = map__len
+	r.Methods[map_type]["keys"] = map__keys
+	r.Methods[map_type]["values"] = map__values
+	r.Methods[map_type]["has"] = map__has
+}
+
+// map.len
+func map__len(_ *runtime.Runtime, args []interface{}) (interface{}, error) {
+	if err := expectArgs("map.len", args, 0, 0); err != nil {
+		return nil, err
+	}
+	return int64(len(args[0].(map[string]interface{}))), nil
+}
+
+// map.keys() returns the sorted keys
+func map__keys(_ *runtime.Runtime, args []interface{}) (interface{}, error) {
+	if err := expectArgs("map.keys", args, 0, 0); err != nil {
+		return nil, err
+	}
+	return toArray(sortedKeys(args[0].(map[string]interface{}))), nil
+}
+
+// map.values()
+func map__values(_ *runtime.Runtime, args []interface{}) (interface{}, error) {
+	if err := expectArgs("map.values", args, 0, 0); err != nil {
+		return nil, err
+	}
+	m := args[0].(map[string]interface{})
+	keys := sortedKeys(m)
+	values := make([]interface{}, len(keys))
+	for i, key := range keys {
+		values[i] = m[key]
+	}
+	return values, nil
+}
+
+// map.has(key)
+func map__has(_ *runtime.Runtime, args []interface{}) (interface{}, error) {
+	if err := expectArgs("map.has", args, 1, 1); err != nil {
+		return nil, err
+	}
+	key, err := stringArg("map.has", args, 1)
+	if err != nil {
+		return nil, err
+	}
+	_, exists := args[0].(map[string]interface{})[key]
+	return exists, nil
+}
+
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,321 @@
+package corelib
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/jomy10/nootlang/runtime"
+)
+
+func registerPrint(r *runtime.Runtime) {
+	r.Funcs["GLOBAL"]["noot!"] = runtime.NewFunctionWithNamedArgs("noot!", printFunc("noot!", "\n", false))
+	r.Funcs["GLOBAL"]["noot"] = runtime.NewFunctionWithNamedArgs("noot", printFunc("noot", "", false))
+	r.Funcs["GLOBAL"]["eprint!"] = runtime.NewFunctionWithNamedArgs("eprint!", printFunc("eprint!", "\n", true))
+	r.Funcs["GLOBAL"]["format"] = runtime.NewFunctionWithNamedArgs("format", format)
+	r.Funcs["GLOBAL"]["printf!"] = runtime.NewFunctionWithNamedArgs("printf!", printf)
+}
+
+// Shows a value the way printing does: strings as they are, tuples as their
+// literal, functions as `<fn name/arity>` and other values as Go formats them
+func Sprint(value interface{}) string {
+	if runtime.ContainsItself(value) {
+		return "<value that contains itself>"
+	}
+	return sprintValue(value)
+}
+
+func sprintValue(value interface{}) string {
+	switch value.(type) {
+	case string:
+		return value.(string)
+	case runtime.Tuple:
+		return reprTuple(value.(runtime.Tuple))
+	case *runtime.Function, runtime.NativeFunction:
+		return Repr(value)
+	case []interface{}:
+		return "[" + sprintElements(value.([]interface{})) + "]"
+	case map[string]interface{}:
+		return sprintMap(value.(map[string]interface{}))
+	default:
+		return fmt.Sprintf("%v", value)
+	}
+}
+
+// The elements separated by spaces, the way `%v` shows them
+func sprintElements(elements []interface{}) string {
+	strs := make([]string, len(elements))
+	for i, element := range elements {
+		strs[i] = sprintValue(element)
+	}
+	return strings.Join(strs, " ")
+}
+
+// `map[key:value ...]` with sorted keys, the way `%v` shows a map
+func sprintMap(m map[string]interface{}) string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	entries := make([]string, len(keys))
+	for i, key := range keys {
+		entries[i] = key + ":" + sprintValue(m[key])
+	}
+	return "map[" + strings.Join(entries, " ") + "]"
+}
+
+// Writes the values separated by `sep` and followed by `end`. Returns what was
+// written
+func Print(w io.Writer, values []interface{}, sep string, end string) (string, error) {
+	var sb strings.Builder
+	for i, value := range values {
+		if i != 0 {
+			sb.WriteString(sep)
+		}
+		sb.WriteString(Sprint(value))
+	}
+	sb.WriteString(end)
+
+	str := sb.String()
+	_, err := io.WriteString(w, str)
+	return str, err
+}
+
+// A print function that takes the named arguments `sep` (" " by default) and
+// `end`
+func printFunc(name string, defaultEnd string, toStderr bool) runtime.NativeFunction {
+	return func(r *runtime.Runtime, args []interface{}) (interface{}, error) {
+		values, named := runtime.SplitNamedArgs(args)
+		sep, end := " ", defaultEnd
+		for key, value := range named {
+			str, isString := value.(string)
+			if !isString {
+				return nil, errors.New(fmt.Sprintf("`%s` expects `%s` to be a string, but got %v", name, key, value))
+			}
+			switch key {
+			case "sep":
+				sep = str
+			case "end":
+				end = str
+			default:
+				return nil, errors.New(fmt.Sprintf("`%s` has no argument `%s`", name, key))
+			}
+		}
+
+		w := r.Stdout
+		if toStderr {
+			w = r.Stderr
+		}
+		return Print(w, values, sep, end)
+	}
+}
+
+// `format(fmt, args...)`
+func format(_ *runtime.Runtime, args []interface{}) (interface{}, error) {
+	return formatArgs("format", args)
+}
+
+// `printf!(fmt, args...)` prints the formatted string, without a newline
+func printf(r *runtime.Runtime, args []interface{}) (interface{}, error) {
+	str, err := formatArgs("printf!", args)
+	if err != nil {
+		return nil, err
+	}
+	_, err = io.WriteString(r.Stdout, str)
+	return str, err
+}
+
+func formatArgs(name string, args []interface{}) (string, error) {
+	args, named := runtime.SplitNamedArgs(args)
+	if len(named) != 0 {
+		return "", errors.New(fmt.Sprintf("`%s` doesn't take named arguments", name))
+	}
+	if len(args) == 0 {
+		return "", errors.New(fmt.Sprintf("`%s` expects a format string", name))
+	}
+	formatStr, isString := args[0].(string)
+	if !isString {
+		return "", errors.New(fmt.Sprintf("`%s` expects a format string, but got %v", name, args[0]))
+	}
+
+	str, err := Format(formatStr, args[1:])
+	if err != nil {
+		return "", errors.New(fmt.Sprintf("`%s`: %s", name, err.Error()))
+	}
+	return str, nil
+}
+
+// Replaces the placeholders in `format` by the arguments, in order. A
+// placeholder is `{}` or `{:spec}`, where spec is
+// `[[fill]align][0][width][.precision]`:
+//
+//	align      `<` left, `>` right or `^` centered. Numbers are aligned to the
+//	           right by default, other values to the left
+//	0          pads numbers with zeros after the sign
+//	width      the minimum amount of characters
+//	precision  the amount of decimals of a number, or the maximum amount of
+//	           characters of other values
+//
+// `{{` and `}}` are a literal `{` and `}`. There has to be a placeholder for
+// every argument
+func Format(format string, args []interface{}) (string, error) {
+	var sb strings.Builder
+	next := 0
+
+	for i := 0; i < len(format); i++ {
+		switch format[i] {
+		case '{':
+			if strings.HasPrefix(format[i:], "{{") {
+				sb.WriteByte('{')
+				i += 1
+				continue
+			}
+			end := strings.IndexByte(format[i:], '}')
+			if end == -1 {
+				return "", errors.New("Unclosed `{` in the format string")
+			}
+			placeholder := format[i+1 : i+end]
+			i += end
+
+			if placeholder != "" && !strings.HasPrefix(placeholder, ":") {
+				return "", errors.New(fmt.Sprintf("Invalid placeholder `{%s}`, expected `{}` or `{:spec}`", placeholder))
+			}
+			index := next
+			next += 1
+			if index >= len(args) {
+				return "", errors.New(fmt.Sprintf("The format string has more placeholders than the %d argument(s)", len(args)))
+			}
+
+			str, err := formatValue(args[index], strings.TrimPrefix(placeholder, ":"))
+			if err != nil {
+				return "", err
+			}
+			sb.WriteString(str)
+		case '}':
+			if !strings.HasPrefix(format[i:], "}}") {
+				return "", errors.New("Unmatched `}` in the format string, use `}}` for a literal `}`")
+			}
+			sb.WriteByte('}')
+			i += 1
+		default:
+			sb.WriteByte(format[i])
+		}
+	}
+
+	if next < len(args) {
+		return "", errors.New(fmt.Sprintf("The format string has placeholders for %d of the %d arguments", next, len(args)))
+	}
+	return sb.String(), nil
+}
+
+// A parsed `[[fill]align][0][width][.precision]`
+type formatSpec struct {
+	fill      rune
+	align     byte // 0 for the default alignment
+	zero      bool
+	width     int
+	precision int // -1 if there is none
+}
+
+func parseFormatSpec(spec string) (formatSpec, error) {
+	parsed := formatSpec{fill: ' ', precision: -1}
+	rest := spec
+
+	isAlign := func(s string) bool {
+		return s != "" && strings.ContainsAny(s[:1], "<>^")
+	}
+	if fill, size := utf8.DecodeRuneInString(rest); rest != "" && isAlign(rest[size:]) {
+		parsed.fill = fill
+		parsed.align = rest[size]
+		rest = rest[size+1:]
+	} else if isAlign(rest) {
+		parsed.align = rest[0]
+		rest = rest[1:]
+	}
+
+	if strings.HasPrefix(rest, "0") {
+		parsed.zero = true
+		rest = rest[1:]
+	}
+
+	widthStr, precisionStr, hasPrecision := strings.Cut(rest, ".")
+	if widthStr != "" {
+		width, err := strconv.Atoi(widthStr)
+		if err != nil || width < 0 {
+			return parsed, errors.New(fmt.Sprintf("Invalid width in the format spec `%s`", spec))
+		}
+		parsed.width = width
+	}
+	if hasPrecision {
+		precision, err := strconv.Atoi(precisionStr)
+		if err != nil || precision < 0 {
+			return parsed, errors.New(fmt.Sprintf("Invalid precision in the format spec `%s`", spec))
+		}
+		parsed.precision = precision
+	}
+	return parsed, nil
+}
+
+func formatValue(value interface{}, specStr string) (string, error) {
+	spec, err := parseFormatSpec(specStr)
+	if err != nil {
+		return "", err
+	}
+
+	var str string
+	isNumber := false
+	switch value.(type) {
+	case int64:
+		isNumber = true
+		if spec.precision != -1 {
+			str = strconv.FormatFloat(float64(value.(int64)), 'f', spec.precision, 64)
+		} else {
+			str = strconv.FormatInt(value.(int64), 10)
+		}
+	case float64:
+		isNumber = true
+		str = strconv.FormatFloat(value.(float64), 'f', spec.precision, 64)
+	default:
+		str = Sprint(value)
+		if spec.precision != -1 && utf8.RuneCountInString(str) > spec.precision {
+			str = string([]rune(str)[:spec.precision])
+		}
+	}
+
+	padding := spec.width - utf8.RuneCountInString(str)
+	if padding <= 0 {
+		return str, nil
+	}
+
+	// Zeros go between the sign and the digits
+	if spec.zero && isNumber && spec.align == 0 {
+		sign := ""
+		if strings.HasPrefix(str, "-") {
+			sign, str = "-", str[1:]
+		}
+		return sign + strings.Repeat("0", padding) + str, nil
+	}
+
+	align := spec.align
+	if align == 0 {
+		align = '<'
+		if isNumber {
+			align = '>'
+		}
+	}
+	fill := string(spec.fill)
+	switch align {
+	case '>':
+		return strings.Repeat(fill, padding) + str, nil
+	case '^':
+		return strings.Repeat(fill, padding/2) + str + strings.Repeat(fill, padding-padding/2), nil
+	default:
+		return str + strings.Repeat(fill, padding), nil
+	}
+}
@@ -0,0 +1,131 @@
+package corelib
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/jomy10/nootlang/runtime"
+)
+
+// Returns the value written as a noot literal, e.g. `[1, "a", true]`. Values
+// that have no literal syntax are shown between angle brackets (e.g. functions
+// as `<fn name/arity>`)
+func Repr(value interface{}) string {
+	if runtime.ContainsItself(value) {
+		return "<value that contains itself>"
+	}
+	return reprValue(value)
+}
+
+func reprValue(value interface{}) string {
+	switch value.(type) {
+	case nil:
+		return "nil"
+	case int64:
+		return strconv.FormatInt(value.(int64), 10)
+	case float64:
+		return reprFloat(value.(float64))
+	case string:
+		return reprString(value.(string))
+	case bool:
+		return strconv.FormatBool(value.(bool))
+	case []interface{}:
+		elements := make([]string, len(value.([]interface{})))
+		for i, element := range value.([]interface{}) {
+			elements[i] = reprValue(element)
+		}
+		return "[" + strings.Join(elements, ", ") + "]"
+	case runtime.Tuple:
+		return reprTuple(value.(runtime.Tuple))
+	case map[string]interface{}:
+		m := value.(map[string]interface{})
+		keys := make([]string, 0, len(m))
+		for key := range m {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		// Noot has no map literals, so maps look the way `Sprint` shows them
+		entries := make([]string, len(keys))
+		for i, key := range keys {
+			entries[i] = reprString(key) + ":" + reprValue(m[key])
+		}
+		return "map[" + strings.Join(entries, " ") + "]"
+	case *runtime.Function:
+		fn := value.(*runtime.Function)
+		if fn.Arity < 0 {
+			return fmt.Sprintf("<fn %s>", fn.Name)
+		}
+		return fmt.Sprintf("<fn %s/%d>", fn.Name, fn.Arity)
+	case runtime.NativeFunction:
+		return "<fn>"
+	case *regexp.Regexp:
+		return "regex(" + reprString(value.(*regexp.Regexp).String()) + ")"
+	case fmt.Stringer:
+		return fmt.Sprintf("<%s>", value.(fmt.Stringer).String())
+	}
+
+	val := reflect.ValueOf(value)
+	for val.Kind() == reflect.Pointer && !val.IsNil() {
+		val = val.Elem()
+	}
+	if val.Kind() == reflect.Struct {
+		return reprStruct(val)
+	}
+	return fmt.Sprintf("<%v>", value)
+}
+
+// `(1, 2)`, a tuple with one element has a trailing comma: `(1,)`
+func reprTuple(tuple runtime.Tuple) string {
+	elements := make([]string, len(tuple))
+	for i, element := range tuple {
+		elements[i] = reprValue(element)
+	}
+	if len(tuple) == 1 {
+		return "(" + elements[0] + ",)"
+	}
+	return "(" + strings.Join(elements, ", ") + ")"
+}
+
+// Floats always have a decimal point, so they can't be mistaken for integers
+func reprFloat(f float64) string {
+	str := strconv.FormatFloat(f, 'g', -1, 64)
+	if !strings.ContainsAny(str, ".eIN") {
+		str += ".0"
+	}
+	return str
+}
+
+// A double quoted string with escape sequences. Curly brackets are escaped as
+// well, so they are not mistaken for interpolation
+func reprString(s string) string {
+	quoted := strconv.Quote(s)
+	quoted = strings.ReplaceAll(quoted, "{", `\{`)
+	return strings.ReplaceAll(quoted, "}", `\}`)
+}
+
+// Structs are written as `Name{field: value, ...}`
+func reprStruct(val reflect.Value) string {
+	var fields []string
+	for i := 0; i < val.NumField(); i++ {
+		field := val.Type().Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		fields = append(fields, field.Name+": "+Repr(val.Field(i).Interface()))
+	}
+	return val.Type().Name() + "{" + strings.Join(fields, ", ") + "}"
+}
+
+// `repr(value)` returns the value written as a noot literal
+func repr(_ *runtime.Runtime, args []interface{}) (interface{}, error) {
+	if runtime.ContainsItself(args[0]) {
+		return nil, errors.New("`repr` cannot show a value that contains itself")
+	}
+	return Repr(args[0]), nil
+}
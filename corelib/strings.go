@@ -0,0 +1,382 @@
+package corelib
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/jomy10/nootlang/runtime"
+)
+
+// Indices and lengths of all string methods are counted in characters (runes),
+// not bytes
+func registerStringMethods(r *runtime.Runtime) {
+	string_type := reflect.TypeOf("")
+	r.Methods[string_type] = make(map[string]runtime.NativeFunction)
+
+	r.Methods[string_type]["concat"] = string__concat
+	r.Methods[string_type]["split"] = string__split
+	r.Methods[string_type]["len"] = string__len
+	r.Methods[string_type]["trim"] = string__trim
+	r.Methods[string_type]["trim_start"] = string__trim_start
+	r.Methods[string_type]["trim_end"] = string__trim_end
+	r.Methods[string_type]["upper"] = string__upper
+	r.Methods[string_type]["lower"] = string__lower
+	r.Methods[string_type]["replace"] = string__replace
+	r.Methods[string_type]["contains"] = string__contains
+	r.Methods[string_type]["starts_with"] = string__starts_with
+	r.Methods[string_type]["ends_with"] = string__ends_with
+	r.Methods[string_type]["index_of"] = string__index_of
+	r.Methods[string_type]["repeat"] = string__repeat
+	r.Methods[string_type]["chars"] = string__chars
+	r.Methods[string_type]["lines"] = string__lines
+	r.Methods[string_type]["pad_left"] = string__pad_left
+	r.Methods[string_type]["pad_right"] = string__pad_right
+	r.Methods[string_type]["substr"] = string__substr
+}
+
+// string.concat
+func string__concat(runtime *runtime.Runtime, args []interface{}) (interface{}, error) {
+	if err := expectArgs("string.concat", args, 1, 1); err != nil {
+		return nil, err
+	}
+
+	lhs, ok := args[0].(string)
+	if !ok {
+		return nil, errors.New("interpreter error")
+	}
+	rhs, ok := args[1].(string)
+	if !ok {
+		rhs = fmt.Sprintf("%v", args[1])
+	}
+
+	// returns a string
+	return lhs + rhs, nil
+}
+
+// string.split(separator) returns an array of strings. The separator can be a
+// string or a regex
+func string__split(runtime *runtime.Runtime, args []interface{}) (interface{}, error) {
+	if err := expectArgs("string.split", args, 1, 1); err != nil {
+		return nil, err
+	}
+
+	lhs, ok := args[0].(string)
+	if !ok {
+		return nil, errors.New("interpreter error")
+	}
+
+	if regex, isRegex := args[1].(*regexp.Regexp); isRegex {
+		return toArray(regex.Split(lhs, -1)), nil
+	}
+
+	rhs, ok := args[1].(string)
+	if !ok {
+		rhs = fmt.Sprintf("%v", args[1])
+	}
+
+	return toArray(strings.Split(lhs, rhs)), nil
+}
+
+// string.len returns the amount of characters
+func string__len(runtime *runtime.Runtime, args []interface{}) (interface{}, error) {
+	if err := expectArgs("string.len", args, 0, 0); err != nil {
+		return nil, err
+	}
+
+	lhs, ok := args[0].(string)
+	if !ok {
+		return nil, errors.New("interpreter error")
+	}
+
+	return int64(utf8.RuneCountInString(lhs)), nil
+}
+
+// string.trim(chars?) removes whitespace, or the characters in `chars`, from
+// both ends
+func string__trim(_ *runtime.Runtime, args []interface{}) (interface{}, error) {
+	return trim("string.trim", args, strings.TrimFunc, strings.Trim)
+}
+
+// string.trim_start(chars?)
+func string__trim_start(_ *runtime.Runtime, args []interface{}) (interface{}, error) {
+	return trim("string.trim_start", args, strings.TrimLeftFunc, strings.TrimLeft)
+}
+
+// string.trim_end(chars?)
+func string__trim_end(_ *runtime.Runtime, args []interface{}) (interface{}, error) {
+	return trim("string.trim_end", args, strings.TrimRightFunc, strings.TrimRight)
+}
+
+func trim(
+	method string,
+	args []interface{},
+	trimFunc func(string, func(rune) bool) string,
+	trimChars func(string, string) string,
+) (interface{}, error) {
+	if err := expectArgs(method, args, 0, 1); err != nil {
+		return nil, err
+	}
+	str := args[0].(string)
+
+	if len(args) == 1 {
+		return trimFunc(str, unicode.IsSpace), nil
+	}
+	chars, err := stringArg(method, args, 1)
+	if err != nil {
+		return nil, err
+	}
+	return trimChars(str, chars), nil
+}
+
+// string.upper
+func string__upper(_ *runtime.Runtime, args []interface{}) (interface{}, error) {
+	if err := expectArgs("string.upper", args, 0, 0); err != nil {
+		return nil, err
+	}
+	return strings.ToUpper(args[0].(string)), nil
+}
+
+// string.lower
+func string__lower(_ *runtime.Runtime, args []interface{}) (interface{}, error) {
+	if err := expectArgs("string.lower", args, 0, 0); err != nil {
+		return nil, err
+	}
+	return strings.ToLower(args[0].(string)), nil
+}
+
+// string.replace(old, new, count?) replaces the first `count` occurrences of
+// `old`, or all of them if no count is given. `old` can also be a regex
+func string__replace(_ *runtime.Runtime, args []interface{}) (interface{}, error) {
+	if err := expectArgs("string.replace", args, 2, 3); err != nil {
+		return nil, err
+	}
+	if regex, isRegex := args[1].(*regexp.Regexp); isRegex {
+		if len(args) == 4 {
+			return nil, errors.New("`string.replace` with a regex always replaces all matches, it can't take a count")
+		}
+		replacement, err := stringArg("string.replace", args, 2)
+		if err != nil {
+			return nil, err
+		}
+		return regex.ReplaceAllString(args[0].(string), replacement), nil
+	}
+
+	old, err := stringArg("string.replace", args, 1)
+	if err != nil {
+		return nil, err
+	}
+	replacement, err := stringArg("string.replace", args, 2)
+	if err != nil {
+		return nil, err
+	}
+	count := -1
+	if len(args) == 4 {
+		if count, err = intArg("string.replace", args, 3); err != nil {
+			return nil, err
+		}
+	}
+	return strings.Replace(args[0].(string), old, replacement, count), nil
+}
+
+// string.contains(substr) where substr is a string or a regex
+func string__contains(_ *runtime.Runtime, args []interface{}) (interface{}, error) {
+	if len(args) == 2 {
+		if regex, isRegex := args[1].(*regexp.Regexp); isRegex {
+			return regex.MatchString(args[0].(string)), nil
+		}
+	}
+	return compareWith("string.contains", args, strings.Contains)
+}
+
+// string.starts_with(prefix)
+func string__starts_with(_ *runtime.Runtime, args []interface{}) (interface{}, error) {
+	return compareWith("string.starts_with", args, strings.HasPrefix)
+}
+
+// string.ends_with(suffix)
+func string__ends_with(_ *runtime.Runtime, args []interface{}) (interface{}, error) {
+	return compareWith("string.ends_with", args, strings.HasSuffix)
+}
+
+func compareWith(method string, args []interface{}, compare func(string, string) bool) (interface{}, error) {
+	if err := expectArgs(method, args, 1, 1); err != nil {
+		return nil, err
+	}
+	other, err := stringArg(method, args, 1)
+	if err != nil {
+		return nil, err
+	}
+	return compare(args[0].(string), other), nil
+}
+
+// string.index_of(substr) returns the index of the first occurrence of `substr`,
+// or -1 if it isn't in the string
+func string__index_of(_ *runtime.Runtime, args []interface{}) (interface{}, error) {
+	if err := expectArgs("string.index_of", args, 1, 1); err != nil {
+		return nil, err
+	}
+	substr, err := stringArg("string.index_of", args, 1)
+	if err != nil {
+		return nil, err
+	}
+
+	str := args[0].(string)
+	index := strings.Index(str, substr)
+	if index == -1 {
+		return int64(-1), nil
+	}
+	return int64(utf8.RuneCountInString(str[:index])), nil
+}
+
+// string.repeat(n)
+func string__repeat(_ *runtime.Runtime, args []interface{}) (interface{}, error) {
+	if err := expectArgs("string.repeat", args, 1, 1); err != nil {
+		return nil, err
+	}
+	n, err := intArg("string.repeat", args, 1)
+	if err != nil {
+		return nil, err
+	}
+	if n < 0 {
+		return nil, errors.New(fmt.Sprintf("`string.repeat` expects a positive count, but got %d", n))
+	}
+	str := args[0].(string)
+	if n != 0 && len(str) > maxStringLength/n {
+		return nil, errors.New(fmt.Sprintf("`string.repeat` would make a string longer than %d bytes", maxStringLength))
+	}
+	return strings.Repeat(str, n), nil
+}
+
+// The longest string `repeat` and the pad methods make. Longer ones are an
+// error, rather than running out of memory
+const maxStringLength = 1 << 28
+
+// string.chars returns an array with every character as a string
+func string__chars(_ *runtime.Runtime, args []interface{}) (interface{}, error) {
+	if err := expectArgs("string.chars", args, 0, 0); err != nil {
+		return nil, err
+	}
+	chars := []interface{}{}
+	for _, char := range args[0].(string) {
+		chars = append(chars, string(char))
+	}
+	return chars, nil
+}
+
+// string.lines returns an array of the lines in the string, without their line
+// endings (`\n` or `\r\n`). A newline at the end doesn't start a new line
+func string__lines(_ *runtime.Runtime, args []interface{}) (interface{}, error) {
+	if err := expectArgs("string.lines", args, 0, 0); err != nil {
+		return nil, err
+	}
+	str := args[0].(string)
+	if str == "" {
+		return []interface{}{}, nil
+	}
+
+	lines := strings.Split(strings.TrimSuffix(str, "\n"), "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimSuffix(line, "\r")
+	}
+	return toArray(lines), nil
+}
+
+// string.pad_left(width, pad?) pads the start of the string with `pad` (a space
+// by default) until it is `width` characters long
+func string__pad_left(_ *runtime.Runtime, args []interface{}) (interface{}, error) {
+	return pad("string.pad_left", args, func(str string, padding string) string { return padding + str })
+}
+
+// string.pad_right(width, pad?)
+func string__pad_right(_ *runtime.Runtime, args []interface{}) (interface{}, error) {
+	return pad("string.pad_right", args, func(str string, padding string) string { return str + padding })
+}
+
+func pad(method string, args []interface{}, join func(string, string) string) (interface{}, error) {
+	if err := expectArgs(method, args, 1, 2); err != nil {
+		return nil, err
+	}
+	width, err := intArg(method, args, 1)
+	if err != nil {
+		return nil, err
+	}
+	padChar := " "
+	if len(args) == 3 {
+		if padChar, err = stringArg(method, args, 2); err != nil {
+			return nil, err
+		}
+		if utf8.RuneCountInString(padChar) != 1 {
+			return nil, errors.New(fmt.Sprintf("`%s` expects a single character to pad with, but got %q", method, padChar))
+		}
+	}
+
+	str := args[0].(string)
+	missing := width - utf8.RuneCountInString(str)
+	if missing <= 0 {
+		return str, nil
+	}
+	if missing > (maxStringLength-len(str))/len(padChar) {
+		return nil, errors.New(fmt.Sprintf("`%s` would make a string longer than %d bytes", method, maxStringLength))
+	}
+	return join(str, strings.Repeat(padChar, missing)), nil
+}
+
+// string.substr(start, end?) returns the characters from `start` up to (but not
+// including) `end`, or up to the end of the string
+func string__substr(_ *runtime.Runtime, args []interface{}) (interface{}, error) {
+	if err := expectArgs("string.substr", args, 1, 2); err != nil {
+		return nil, err
+	}
+	chars := []rune(args[0].(string))
+
+	start, err := intArg("string.substr", args, 1)
+	if err != nil {
+		return nil, err
+	}
+	end := len(chars)
+	if len(args) == 3 {
+		if end, err = intArg("string.substr", args, 2); err != nil {
+			return nil, err
+		}
+	}
+
+	if start < 0 || end > len(chars) || start > end {
+		return nil, errors.New(fmt.Sprintf("`string.substr` range %d..%d is out of bounds for a string of length %d", start, end, len(chars)))
+	}
+	return string(chars[start:end]), nil
+}
+
+// array.join(separator) joins the elements into a string. Elements that aren't
+// strings are formatted
+func array__join(_ *runtime.Runtime, args []interface{}) (interface{}, error) {
+	if err := expectArgs("array.join", args, 0, 1); err != nil {
+		return nil, err
+	}
+	separator := ""
+	if len(args) == 2 {
+		var err error
+		if separator, err = stringArg("array.join", args, 1); err != nil {
+			return nil, err
+		}
+	}
+
+	elements := args[0].([]interface{})
+	strs := make([]string, len(elements))
+	for i, element := range elements {
+		strs[i] = Sprint(element)
+	}
+	return strings.Join(strs, separator), nil
+}
+
+func toArray(strs []string) []interface{} {
+	arr := make([]interface{}, len(strs))
+	for i, str := range strs {
+		arr[i] = str
+	}
+	return arr
+}
@@ -0,0 +1,41 @@
+package corelib
+
+import (
+	"reflect"
+
+	"github.com/jomy10/nootlang/runtime"
+)
+
+// Tuples are immutable, so they only have methods that read them
+func registerTupleMethods(r *runtime.Runtime) {
+	tuple_type := reflect.TypeOf(runtime.Tuple{})
+	r.Methods[tuple_type] = make(map[string]runtime.NativeFunction)
+
+	r.Methods[tuple_type]["len"] = tuple__len
+	r.Methods[tuple_type]["contains"] = tuple__contains
+	r.Methods[tuple_type]["to_array"] = tuple__to_array
+}
+
+// tuple.len
+func tuple__len(_ *runtime.Runtime, args []interface{}) (interface{}, error) {
+	if err := expectArgs("tuple.len", args, 0, 0); err != nil {
+		return nil, err
+	}
+	return int64(len(args[0].(runtime.Tuple))), nil
+}
+
+// tuple.contains(value)
+func tuple__contains(_ *runtime.Runtime, args []interface{}) (interface{}, error) {
+	if err := expectArgs("tuple.contains", args, 1, 1); err != nil {
+		return nil, err
+	}
+	return indexOf(args[0].(runtime.Tuple), args[1]) != -1, nil
+}
+
+// tuple.to_array() returns the elements as a new array
+func tuple__to_array(_ *runtime.Runtime, args []interface{}) (interface{}, error) {
+	if err := expectArgs("tuple.to_array", args, 0, 0); err != nil {
+		return nil, err
+	}
+	return append([]interface{}{}, args[0].(runtime.Tuple)...), nil
+}
@@ -0,0 +1,179 @@
+package corelib
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jomy10/nootlang/runtime"
+)
+
+// The parameter of the functions that take a single value
+var valueParam = []runtime.Param{{Name: "value"}}
+
+func registerTypes(r *runtime.Runtime) {
+	r.Funcs["GLOBAL"]["type_of"] = runtime.NewFunctionWithParams("type_of", valueParam, type_of)
+
+	r.Funcs["GLOBAL"]["is_nil"] = runtime.NewFunctionWithParams("is_nil", valueParam, isType("nil"))
+	r.Funcs["GLOBAL"]["is_int"] = runtime.NewFunctionWithParams("is_int", valueParam, isType("int"))
+	r.Funcs["GLOBAL"]["is_float"] = runtime.NewFunctionWithParams("is_float", valueParam, isType("float"))
+	r.Funcs["GLOBAL"]["is_number"] = runtime.NewFunctionWithParams("is_number", valueParam, isType("int", "float"))
+	r.Funcs["GLOBAL"]["is_string"] = runtime.NewFunctionWithParams("is_string", valueParam, isType("string"))
+	r.Funcs["GLOBAL"]["is_bool"] = runtime.NewFunctionWithParams("is_bool", valueParam, isType("bool"))
+	r.Funcs["GLOBAL"]["is_array"] = runtime.NewFunctionWithParams("is_array", valueParam, isType("array"))
+	r.Funcs["GLOBAL"]["is_tuple"] = runtime.NewFunctionWithParams("is_tuple", valueParam, isType("tuple"))
+	r.Funcs["GLOBAL"]["is_map"] = runtime.NewFunctionWithParams("is_map", valueParam, isType("map"))
+	r.Funcs["GLOBAL"]["is_function"] = runtime.NewFunctionWithParams("is_function", valueParam, isType("function"))
+
+	r.Funcs["GLOBAL"]["str"] = runtime.NewFunctionWithParams("str", valueParam, str)
+	r.Funcs["GLOBAL"]["int"] = runtime.NewFunctionWithParams("int", valueParam, convertInt)
+	r.Funcs["GLOBAL"]["float"] = runtime.NewFunctionWithParams("float", valueParam, convertFloat)
+	r.Funcs["GLOBAL"]["bool"] = runtime.NewFunctionWithParams("bool", valueParam, convertBool)
+}
+
+// The name of a type as scripts see it, e.g. "int" or "array"
+func TypeName(ty reflect.Type) string {
+	if ty == nil {
+		return "nil"
+	}
+	switch ty {
+	case reflect.TypeOf(int64(0)):
+		return "int"
+	case reflect.TypeOf(float64(0)):
+		return "float"
+	case reflect.TypeOf(""):
+		return "string"
+	case reflect.TypeOf(false):
+		return "bool"
+	case reflect.TypeOf([]interface{}{}):
+		return "array"
+	case reflect.TypeOf(runtime.Tuple{}):
+		return "tuple"
+	case reflect.TypeOf(map[string]interface{}{}):
+		return "map"
+	case reflect.TypeOf(&runtime.Function{}), reflect.TypeOf(runtime.NativeFunction(nil)):
+		return "function"
+	case reflect.TypeOf(&runtime.Module{}):
+		return "module"
+	case reflect.TypeOf(&runtime.Interface{}):
+		return "interface"
+	case reflect.TypeOf(&regexp.Regexp{}):
+		return "regex"
+	case reflect.TypeOf(time.Time{}):
+		return "time"
+	case reflect.TypeOf(time.Duration(0)):
+		return "duration"
+	default:
+		return ty.String()
+	}
+}
+
+// The type name of a value
+func TypeOf(value interface{}) string {
+	return TypeName(reflect.TypeOf(value))
+}
+
+// `type_of(x)`
+func type_of(_ *runtime.Runtime, args []interface{}) (interface{}, error) {
+	return TypeOf(args[0]), nil
+}
+
+// `is_<type>(x)`, true if x has one of the types
+func isType(names ...string) runtime.NativeFunction {
+	return func(_ *runtime.Runtime, args []interface{}) (interface{}, error) {
+		name := TypeOf(args[0])
+		for _, n := range names {
+			if n == name {
+				return true, nil
+			}
+		}
+		return false, nil
+	}
+}
+
+// `str(x)` the value as it is printed
+func str(_ *runtime.Runtime, args []interface{}) (interface{}, error) {
+	return Sprint(args[0]), nil
+}
+
+// `int(x)` converts a float (rounding towards zero), a decimal string or a
+// bool (1 or 0) to an integer
+func convertInt(_ *runtime.Runtime, args []interface{}) (interface{}, error) {
+	switch args[0].(type) {
+	case int64:
+		return args[0], nil
+	case float64:
+		f := math.Trunc(args[0].(float64))
+		if math.IsNaN(f) || f < math.MinInt64 || f >= math.MaxInt64 {
+			return nil, errors.New(fmt.Sprintf("`int`: %v doesn't fit in an integer", args[0]))
+		}
+		return int64(f), nil
+	case string:
+		n, err := strconv.ParseInt(strings.TrimSpace(args[0].(string)), 10, 64)
+		if err != nil {
+			return nil, errors.New(fmt.Sprintf("`int`: cannot convert %s to an integer (%s)", reprString(args[0].(string)), conversionError(err)))
+		}
+		return n, nil
+	case bool:
+		if args[0].(bool) {
+			return int64(1), nil
+		}
+		return int64(0), nil
+	default:
+		return nil, errors.New(fmt.Sprintf("`int`: cannot convert a value of type %s to an integer", TypeOf(args[0])))
+	}
+}
+
+// `float(x)` converts an integer or a string to a float
+func convertFloat(_ *runtime.Runtime, args []interface{}) (interface{}, error) {
+	switch args[0].(type) {
+	case int64:
+		return float64(args[0].(int64)), nil
+	case float64:
+		return args[0], nil
+	case string:
+		f, err := strconv.ParseFloat(strings.TrimSpace(args[0].(string)), 64)
+		if err != nil {
+			return nil, errors.New(fmt.Sprintf("`float`: cannot convert %s to a float (%s)", reprString(args[0].(string)), conversionError(err)))
+		}
+		return f, nil
+	default:
+		return nil, errors.New(fmt.Sprintf("`float`: cannot convert a value of type %s to a float", TypeOf(args[0])))
+	}
+}
+
+// `bool(x)` converts "true" and "false", and numbers (0 is false)
+func convertBool(_ *runtime.Runtime, args []interface{}) (interface{}, error) {
+	switch args[0].(type) {
+	case bool:
+		return args[0], nil
+	case int64:
+		return args[0].(int64) != 0, nil
+	case float64:
+		return args[0].(float64) != 0, nil
+	case string:
+		switch strings.TrimSpace(args[0].(string)) {
+		case "true":
+			return true, nil
+		case "false":
+			return false, nil
+		default:
+			return nil, errors.New(fmt.Sprintf("`bool`: cannot convert %s to a bool, expected \"true\" or \"false\"", reprString(args[0].(string))))
+		}
+	default:
+		return nil, errors.New(fmt.Sprintf("`bool`: cannot convert a value of type %s to a bool", TypeOf(args[0])))
+	}
+}
+
+// Why strconv couldn't parse a number
+func conversionError(err error) string {
+	if errors.Is(err, strconv.ErrRange) {
+		return "out of range"
+	}
+	return "not a number"
+}
@@ -1,6 +1,8 @@
 package interpreter
 
 import (
+	"bytes"
+	"os"
 	"testing"
 )
 
@@ -11,3 +13,311 @@ func TestStringConcat(t *testing.T) {
 		t,
 	)
 }
+
+func TestDoc(t *testing.T) {
+	testWithResult(
+		"/// Adds two numbers\ndef add(a, b) { return a + b }\ndef sub(a, b) { return a - b }\nresult := [doc(add), doc(sub)]",
+		[]interface{}{"Adds two numbers", nil},
+		t,
+	)
+}
+
+func TestRepr(t *testing.T) {
+	testWithResult(
+		`def add(a, b) { return a + b }; result := repr([1, 2.0, "a\"\{b\}\n", true, nil, [add, noot!]])`,
+		`[1, 2.0, "a\"\{b\}\n", true, nil, [<fn add/2>, <fn noot!>]]`,
+		t,
+	)
+	testWithResult(
+		`m := json.parse("\{\"b\": [1, \"x\"], \"a\": \{\}\}")
+result := [repr(m), str(m)]`,
+		[]interface{}{`map["a":map[] "b":[1, "x"]]`, `map[a:map[] b:[1 x]]`},
+		t,
+	)
+}
+
+func TestStringMethods(t *testing.T) {
+	testWithResult(
+		`s := "  Héllo wörld "
+t := s.trim()
+result := [t.upper(), t.lower(), t.len(), t.index_of("w"), t.substr(1, 5), t.replace("l", "L", 2), t.contains("wö"), t.starts_with("Hé"), t.ends_with("x")]`,
+		[]interface{}{"HÉLLO WÖRLD", "héllo wörld", int64(11), int64(6), "éllo", "HéLLo wörld", true, true, false},
+		t,
+	)
+	testWithResult(
+		`arr := ["a", 1, true]
+result := ["a,b,c".split(","), "xxabxx".trim("x"), "  a ".trim_start(), "  a ".trim_end(), "ab".repeat(3), "añ".chars(), "one\r\ntwo\n".lines(), "7".pad_left(3, "0"), "ab".pad_right(4), arr.join("-")]`,
+		[]interface{}{[]interface{}{"a", "b", "c"}, "ab", "a ", "  a", "ababab", []interface{}{"a", "ñ"}, []interface{}{"one", "two"}, "007", "ab  ", "a-1-true"},
+		t,
+	)
+}
+
+func TestStringMethodErrors(t *testing.T) {
+	for _, source := range []string{
+		`a := "abc".substr(2, 5)`,
+		`n := 0 - 1; a := "abc".repeat(n)`,
+		`a := "ab".repeat(9223372036854775807)`,
+		`a := "ab".repeat(200000000)`,
+		`a := "ab".pad_left(9223372036854775807)`,
+		`a := "ab".pad_right(300000000, "é")`,
+		`a := "abc".upper(1)`,
+		`a := "abc".contains(1)`,
+	} {
+		if err := testWithError(source, t); err == nil {
+			t.Errorf("Expected an error for %s", source)
+		}
+	}
+}
+
+// Elements are joined the way `noot!` prints them
+func TestArrayJoin(t *testing.T) {
+	testWithResult(
+		`result := [[[1, 2], nil, "a", 1.5, true].join(","), [].join(",")]`,
+		[]interface{}{"[1 2],<nil>,a,1.5,true", ""},
+		t,
+	)
+}
+
+func TestArrayMutatingMethods(t *testing.T) {
+	testWithResult(
+		`a := [1, 2]
+a.push(3, 4)
+last := a.pop()
+a.insert(0, 0)
+removed := a.remove(1)
+result := [a, last, removed]`,
+		[]interface{}{[]interface{}{int64(0), int64(2), int64(3)}, int64(4), int64(1)},
+		t,
+	)
+}
+
+// `push` appends in place, which doesn't change copies of the array
+func TestArrayPushInPlace(t *testing.T) {
+	testWithResult(
+		`a := [1, 2]
+popped := a.pop()
+b := a
+a.push(3)
+b.push(4)
+c := []
+i := 0
+while i < 1000 {
+  c.push(i)
+  i += 1
+}
+d := [1, 2]
+e := d
+d.pop()
+d.push(5)
+f := [[1]]
+f[0].push(2)
+g := f[0]
+f[0].push(3)
+g.push(4)
+result := [a, b, c.len(), c[999], e, f, g]`,
+		[]interface{}{
+			[]interface{}{int64(1), int64(3)},
+			[]interface{}{int64(1), int64(4)},
+			int64(1000),
+			int64(999),
+			[]interface{}{int64(1), int64(2)},
+			[]interface{}{[]interface{}{int64(1), int64(2), int64(3)}},
+			[]interface{}{int64(1), int64(2), int64(4)},
+		},
+		t,
+	)
+}
+
+// Changes made by methods are stored where the receiver came from
+func TestMutatingMethodReceivers(t *testing.T) {
+	testWithResult(
+		`m := json.parse("\{\"xs\": [1]\}")
+m["xs"].push(2)
+m.xs.push(3)
+arr := [[1], [2]]
+arr[0].push(5)
+last := arr[1].pop()
+nested := [[[1]]]
+nested[0][0].push(2)
+b := [1]
+c := b
+b[0] = 9
+b.push(1)
+result := [m, arr, last, nested, b, c]`,
+		[]interface{}{
+			map[string]interface{}{"xs": []interface{}{int64(1), int64(2), int64(3)}},
+			[]interface{}{[]interface{}{int64(1), int64(5)}, []interface{}{}},
+			int64(2),
+			[]interface{}{[]interface{}{[]interface{}{int64(1), int64(2)}}},
+			[]interface{}{int64(9), int64(1)},
+			[]interface{}{int64(9)},
+		},
+		t,
+	)
+}
+
+func TestMutatingMethodReceiverErrors(t *testing.T) {
+	for source, expected := range map[string]string{
+		"x := [1, 2].pop()":             "Cannot change a temporary value with `pop`, store it in a variable first",
+		"const L = [[1]]\nL[0].push(2)": "Cannot change constant `L` with `push`",
+		"t := ([1], 2)\nt[0].push(2)":   "Cannot change an element of a tuple with `push`",
+		"a := [[1]]\na[3].push(1)":      "Index 3 is out of range, the length is 1",
+	} {
+		err := testWithError(source, t)
+		if err == nil || err.Error() != expected {
+			t.Errorf("Got %v for %s, but expected %s", err, source, expected)
+		}
+	}
+}
+
+func TestArrayMethods(t *testing.T) {
+	testWithResult(
+		`a := [3, 1, 2, 1]
+nested := [[1, 2], 3, [4]]
+words := ["b", "a"]
+result := [a.slice(1, 3), a.reverse(), a.sort(), a.contains(2), a.index_of(5), a.unique(), nested.flatten(), a.min(), a.max(), a.sum(), words.zip(a), words.enumerate(), words.sort()]`,
+		[]interface{}{
+			[]interface{}{int64(1), int64(2)},
+			[]interface{}{int64(1), int64(2), int64(1), int64(3)},
+			[]interface{}{int64(1), int64(1), int64(2), int64(3)},
+			true,
+			int64(-1),
+			[]interface{}{int64(3), int64(1), int64(2)},
+			[]interface{}{int64(1), int64(2), int64(3), int64(4)},
+			int64(1),
+			int64(3),
+			int64(7),
+			[]interface{}{[]interface{}{"b", int64(3)}, []interface{}{"a", int64(1)}},
+			[]interface{}{[]interface{}{int64(0), "b"}, []interface{}{int64(1), "a"}},
+			[]interface{}{"a", "b"},
+		},
+		t,
+	)
+}
+
+func TestArrayCallbacks(t *testing.T) {
+	testWithResult(
+		`def double(x) { return x * 2 }
+def is_big(x) { return x > 2 }
+def add(a, b) { return a + b }
+def desc(a, b) { return a > b }
+a := [1, 2, 3]
+result := [a.map(double), a.filter(is_big), a.reduce(add), a.reduce(add, 10), a.any(is_big), a.all(is_big), a.sort(desc)]`,
+		[]interface{}{
+			[]interface{}{int64(2), int64(4), int64(6)},
+			[]interface{}{int64(3)},
+			int64(6),
+			int64(16),
+			true,
+			false,
+			[]interface{}{int64(3), int64(2), int64(1)},
+		},
+		t,
+	)
+}
+
+func TestArrayMethodErrors(t *testing.T) {
+	for _, source := range []string{
+		`a := []; b := a.pop()`,
+		`a := [1]; b := a.remove(1)`,
+		`a := [1, "a"]; b := a.sort()`,
+		`a := ["a"]; b := a.sum()`,
+		`a := [1]; b := a.map(1)`,
+		`def f(x) { return 1 }; a := [1]; b := a.filter(f)`,
+	} {
+		if err := testWithError(source, t); err == nil {
+			t.Errorf("Expected an error for %s", source)
+		}
+	}
+}
+
+func TestPrintFunctions(t *testing.T) {
+	testWithOutput(`noot!("a", 1, 2.5); noot("no newline"); noot!(); noot!(1, 2, 3, sep = ", "); noot!("x", end = "!\n"); noot("a", "b", sep = "-", end = ";")`,
+		"a 1 2.5\nno newline\n1, 2, 3\nx!\na-b;", t)
+}
+
+func TestEprint(t *testing.T) {
+	stdout := new(bytes.Buffer)
+	stderr := new(bytes.Buffer)
+	if err := Interpret(nodes(`eprint!("oh", "no"); noot!("fine")`, t), stdout, stderr, os.Stdin, nil); err != nil {
+		t.Fatal(err)
+	}
+	if stdout.String() != "fine\n" || stderr.String() != "oh no\n" {
+		t.Fatalf("Got stdout %q and stderr %q", stdout.String(), stderr.String())
+	}
+}
+
+func TestFormat(t *testing.T) {
+	testWithResult(
+		`name := "noot"
+result := [format("{} has {:>5} points", name, 42), format("{:<6}|{:^7}|{:*>4}", "ab", "mid", 7), format("{:.2} {:.0} {:8.3}", 3.14159, 2.5, -1.5), format("{:05} {:05}", 42, -42), format(`+"`{{literal}} {:.3}`"+`, "truncated"), format("{:>4}", "🐧")]`,
+		[]interface{}{"noot has    42 points", "ab    |  mid  |***7", "3.14 2   -1.500", "00042 -0042", "{literal} tru", "   🐧"},
+		t,
+	)
+	testWithOutput(`printf!("{}-{}", 1, 2); printf!("\n")`, "1-2\n", t)
+}
+
+func TestFormatErrors(t *testing.T) {
+	for _, source := range []string{
+		`a := format("{} {}", 1)`,
+		`a := format("{}", 1, 2)`,
+		"a := format(`{`, 1)",
+		"a := format(`}`)",
+		`a := format("{:x}", 1)`,
+		`a := format(1)`,
+		`noot!(1, sep = 2)`,
+		`noot!(1, color = "red")`,
+		`noot!(1, sep = "a", sep = "b")`,
+		`def f(a) { return a }; b := f(c = 1)`,
+	} {
+		if err := testWithError(source, t); err == nil {
+			t.Errorf("Expected an error for %s", source)
+		}
+	}
+}
+
+func TestTypeOf(t *testing.T) {
+	testWithResult(
+		`def f() {}
+result := [type_of(1), type_of(1.5), type_of("a"), type_of(true), type_of(nil), type_of([1]), type_of(f), type_of(noot!), type_of(json.parse("{}")), type_of(math), type_of(regex("a")), type_of(time.seconds(1))]`,
+		[]interface{}{"int", "float", "string", "bool", "nil", "array", "function", "function", "map", "module", "regex", "duration"},
+		t,
+	)
+}
+
+func TestTypePredicates(t *testing.T) {
+	testWithResult(
+		`def f() {}
+result := [is_int(1), is_int(1.0), is_float(1.0), is_number(1), is_number(1.5), is_number("1"), is_string("a"), is_bool(false), is_nil(nil), is_nil(0), is_array([]), is_map(json.parse("{}")), is_function(f), is_function("f")]`,
+		[]interface{}{true, false, true, true, true, false, true, true, true, false, true, true, true, false},
+		t,
+	)
+}
+
+func TestConversions(t *testing.T) {
+	testWithResult(
+		`result := [str(1), str(2.5), str(true), str(nil), str([1, 2]), int("42"), int(" -7 "), int(3.9), int(-3.9), int(true), float("2.5"), float(2), float("1e3"), bool("true"), bool("false"), bool(0), bool(2.5), "n = " + str(1)]`,
+		[]interface{}{"1", "2.5", "true", "<nil>", "[1 2]", int64(42), int64(-7), int64(3), int64(-3), int64(1), 2.5, 2.0, 1000.0, true, false, false, true, "n = 1"},
+		t,
+	)
+}
+
+func TestConversionErrors(t *testing.T) {
+	tests := map[string]string{
+		`a := int("12a")`:                  "`int`: cannot convert \"12a\" to an integer (not a number)",
+		`a := int("99999999999999999999")`: "`int`: cannot convert \"99999999999999999999\" to an integer (out of range)",
+		`a := int([1])`:                    "`int`: cannot convert a value of type array to an integer",
+		`a := float("one")`:                "`float`: cannot convert \"one\" to a float (not a number)",
+		`a := bool("yes")`:                 "`bool`: cannot convert \"yes\" to a bool, expected \"true\" or \"false\"",
+		`a := 1 + "2"`:                     "Cannot apply `+` to int and string",
+		`a := 1.5 * "2"`:                   "Cannot apply `*` to float and string",
+		`a := true && "true"`:              "Cannot apply `&&` to bool and string",
+		`a := true == 0`:                   "Cannot apply `==` to bool and int",
+	}
+	for source, expected := range tests {
+		err := testWithError(source, t)
+		if err == nil || err.Error() != expected {
+			t.Errorf("Expected the error %q for %s, but got %v", expected, source, err)
+		}
+	}
+}
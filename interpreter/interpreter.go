@@ -1,6 +1,7 @@
 package interpreter
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"github.com/jomy10/nootlang/corelib"
@@ -8,12 +9,17 @@ import (
 	runtime "github.com/jomy10/nootlang/runtime"
 	"io"
 	"reflect"
-	"strconv"
 	"strings"
 )
 
 func Interpret(nodes []parser.Node, stdout, stderr io.Writer, stdin io.Reader, registerLibs []func(*runtime.Runtime)) error {
+	return InterpretContext(context.Background(), nodes, stdout, stderr, stdin, registerLibs)
+}
+
+// Like `Interpret`, but stops with the context's error when it is cancelled
+func InterpretContext(ctx context.Context, nodes []parser.Node, stdout, stderr io.Writer, stdin io.Reader, registerLibs []func(*runtime.Runtime)) error {
 	runtime := runtime.NewRuntime(stdout, stderr, stdin)
+	runtime.Context = ctx
 	corelib.Register(&runtime)
 	// Load any other languages passed to the interpreter
 	for _, registerLib := range registerLibs {
@@ -34,38 +40,52 @@ func Interpret(nodes []parser.Node, stdout, stderr io.Writer, stdin io.Reader, r
 func ExecNode(runtime *runtime.Runtime, node parser.Node) (interface{}, error) {
 	// fmt.Printf("Node: %#v\n", node)
 	switch node.(type) {
+	case parser.ConstDeclNode:
+		return nil, execConstDecl(runtime, node.(parser.ConstDeclNode))
 	case parser.VarDeclNode:
 		return nil, execVarDecl(runtime, node.(parser.VarDeclNode))
+	case parser.DestructuringDeclNode:
+		return nil, execDestructuringDecl(runtime, node.(parser.DestructuringDeclNode))
 	case parser.VarAssignNode:
 		return nil, execVarAssign(runtime, node.(parser.VarAssignNode))
 	case parser.FunctionCallExprNode:
 		return execFuncCallNode(runtime, node.(parser.FunctionCallExprNode))
+	case parser.CallExprNode:
+		return execCallExprNode(runtime, node.(parser.CallExprNode))
 	case parser.MethodCallExprNode:
 		return execMethodCallNode(runtime, node.(parser.MethodCallExprNode))
+	case parser.FieldAccessNode:
+		return execRead(runtime, node)
 	case parser.IntegerLiteralNode:
 		return node.(parser.IntegerLiteralNode).Value, nil
 	case parser.NilLiteralNode:
 		return nil, nil
 	case parser.StringLiteralNode:
 		return node.(parser.StringLiteralNode).String, nil
+	case parser.InterpolatedStringNode:
+		return execInterpolatedString(runtime, node.(parser.InterpolatedStringNode))
 	case parser.FloatLiteralNode:
 		return node.(parser.FloatLiteralNode).Value, nil
 	case parser.BoolLiteralNode:
 		return node.(parser.BoolLiteralNode).Value, nil
 	case parser.ArrayLiteralNode:
 		return execArrayLiteral(runtime, node.(parser.ArrayLiteralNode))
+	case parser.TupleLiteralNode:
+		return execTupleLiteral(runtime, node.(parser.TupleLiteralNode))
 	case parser.VariableNode:
-		return runtime.GetVar(node.(parser.VariableNode).Name)
+		return execRead(runtime, node)
 	case parser.BinaryExpressionNode:
 		return execBinaryExpressionNode(runtime, node.(parser.BinaryExpressionNode))
 	case parser.FunctionDeclNode:
 		return newFunction(runtime, node.(parser.FunctionDeclNode))
+	case parser.InterfaceDeclNode:
+		return nil, execInterfaceDecl(runtime, node.(parser.InterfaceDeclNode))
 	case parser.ReturnNode:
 		return ExecNode(runtime, node.(parser.ReturnNode).Expr)
 	case parser.BinaryNotNode:
 		return execBinaryNotExpressionNode(runtime, node.(parser.BinaryNotNode))
 	case parser.ArrayIndexNode:
-		return execArrayIndexNode(runtime, node.(parser.ArrayIndexNode))
+		return execRead(runtime, node)
 	case parser.ArrayIndexAssignmentNode:
 		return nil, execArrayIndexAssignmentNode(runtime, node.(parser.ArrayIndexAssignmentNode))
 	case parser.IfNode:
@@ -78,49 +98,114 @@ func ExecNode(runtime *runtime.Runtime, node parser.Node) (interface{}, error) {
 	return nil, errors.New(fmt.Sprintf("Noot error: Invalid node `%#v`", node))
 }
 
-func execArrayIndexAssignmentNode(runtime *runtime.Runtime, node parser.ArrayIndexAssignmentNode) error {
-	idx, err := ExecNode(runtime, node.Index)
+func execArrayIndexAssignmentNode(_runtime *runtime.Runtime, node parser.ArrayIndexAssignmentNode) error {
+	idx, err := ExecNode(_runtime, node.Index)
 	if err != nil {
 		return err
 	}
-	val, err := ExecNode(runtime, node.Rhs)
+	val, err := ExecNode(_runtime, node.Rhs)
 	if err != nil {
 		return err
 	}
 
+	collection, err := _runtime.GetVar(node.Array.Name)
+	if err != nil {
+		return err
+	}
+	if _runtime.IsConst(node.Array.Name) {
+		return errors.New(fmt.Sprintf("Cannot assign to an element of constant `%s`", node.Array.Name))
+	}
+	switch collection.(type) {
+	case runtime.Tuple:
+		return errors.New(fmt.Sprintf("Cannot assign to an element of `%s`, tuples are immutable", node.Array.Name))
+	case map[string]interface{}:
+		key, isString := idx.(string)
+		if !isString {
+			return errors.New("Only strings can be used as map keys")
+		}
+		collection.(map[string]interface{})[key] = val
+		return nil
+	}
+
 	switch idx.(type) {
 	case int64:
-		// ok
-		runtime.SetArrayIndex(node.Array.Name, idx.(int64), val)
-		return nil
+		return _runtime.SetArrayIndex(node.Array.Name, idx.(int64), val)
 	default:
 		return errors.New("Only integers can be used for array indexing")
 	}
 }
 
-// Return the value
-func execArrayIndexNode(runtime *runtime.Runtime, node parser.ArrayIndexNode) (interface{}, error) {
-	array, err := ExecNode(runtime, node.Array)
+// Reads a variable, element or field. Values read from a constant are copied,
+// so a constant can't be changed through a variable or parameter holding it
+func execRead(_runtime *runtime.Runtime, node parser.Node) (interface{}, error) {
+	value, err := execStored(_runtime, node)
 	if err != nil {
 		return nil, err
 	}
-	switch array.(type) {
-	case []interface{}:
-		idx, err := ExecNode(runtime, node.Index)
+	if root := rootVariable(node); root != "" && _runtime.IsConst(root) {
+		return runtime.Copy(value), nil
+	}
+	return withoutSpareCapacity(value), nil
+}
+
+// The value stored in a variable, element or field, it isn't copied
+func execStored(_runtime *runtime.Runtime, node parser.Node) (interface{}, error) {
+	switch node.(type) {
+	case parser.VariableNode:
+		return _runtime.GetVar(node.(parser.VariableNode).Name)
+	case parser.ArrayIndexNode:
+		indexNode := node.(parser.ArrayIndexNode)
+		array, err := execStored(_runtime, indexNode.Array)
 		if err != nil {
 			return nil, err
 		}
-		switch idx.(type) {
-		case int64:
-			return array.([]interface{})[idx.(int64)], nil
-		default:
-			return nil, errors.New("Only integer values can be used to index an aray")
+		idx, err := ExecNode(_runtime, indexNode.Index)
+		if err != nil {
+			return nil, err
+		}
+		return indexValue(array, idx)
+	case parser.FieldAccessNode:
+		fieldNode := node.(parser.FieldAccessNode)
+		of, err := execStored(_runtime, fieldNode.Of)
+		if err != nil {
+			return nil, err
+		}
+		return fieldValue(of, fieldNode.Name)
+	default:
+		return ExecNode(_runtime, node)
+	}
+}
+
+// The element of an array or tuple, or the value of a map key
+func indexValue(array interface{}, idx interface{}) (interface{}, error) {
+	switch array.(type) {
+	case []interface{}:
+		return indexElements(array.([]interface{}), idx)
+	case runtime.Tuple:
+		return indexElements(array.(runtime.Tuple), idx)
+	case map[string]interface{}:
+		key, isString := idx.(string)
+		if !isString {
+			return nil, errors.New("Only strings can be used as map keys")
 		}
+		// Missing keys are nil
+		return array.(map[string]interface{})[key], nil
 	default:
 		return nil, errors.New("Cannot index non-array type")
 	}
 }
 
+func indexElements(elements []interface{}, idx interface{}) (interface{}, error) {
+	i, isInt := idx.(int64)
+	if !isInt {
+		return nil, errors.New("Only integer values can be used to index an aray")
+	}
+	if i < 0 || i >= int64(len(elements)) {
+		return nil, errors.New(fmt.Sprintf("Index %d is out of range, the length is %d", i, len(elements)))
+	}
+	return elements[i], nil
+}
+
 func execArrayLiteral(runtime *runtime.Runtime, node parser.ArrayLiteralNode) (interface{}, error) {
 	arr := make([]interface{}, len(node.Values))
 	for i, element := range node.Values {
@@ -133,15 +218,33 @@ func execArrayLiteral(runtime *runtime.Runtime, node parser.ArrayLiteralNode) (i
 	return arr, nil
 }
 
-func execWhile(runtime *runtime.Runtime, node parser.WhileNode) error {
-	scopeStringBuilder := strings.Builder{}
-	scopeStringBuilder.WriteString(runtime.CurrentScope())
-	scopeStringBuilder.WriteString("$__$")
-	scopeStringBuilder.WriteString("while")
-	scope := scopeStringBuilder.String()
+func execTupleLiteral(_runtime *runtime.Runtime, node parser.TupleLiteralNode) (interface{}, error) {
+	arr, err := execArrayLiteral(_runtime, parser.ArrayLiteralNode{Values: node.Values})
+	if err != nil {
+		return nil, err
+	}
+	return runtime.Tuple(arr.([]interface{})), nil
+}
 
-whileLoop:
+// Evaluates every part of the string and concatenates them
+func execInterpolatedString(runtime *runtime.Runtime, node parser.InterpolatedStringNode) (interface{}, error) {
+	var sb strings.Builder
+	for _, part := range node.Parts {
+		val, err := ExecNode(runtime, part)
+		if err != nil {
+			return nil, err
+		}
+		sb.WriteString(corelib.Sprint(val))
+	}
+	return sb.String(), nil
+}
+
+func execWhile(runtime *runtime.Runtime, node parser.WhileNode) error {
 	for {
+		if err := runtime.Context.Err(); err != nil {
+			return err
+		}
+
 		condVal, err := ExecNode(runtime, node.Condition)
 		if err != nil {
 			return err
@@ -150,24 +253,16 @@ whileLoop:
 		switch condVal.(type) {
 		case bool:
 			if !(condVal.(bool)) {
-				break whileLoop
+				return nil
 			}
-
-			runtime.AddScope(scope)
-			for _, node := range node.Body {
-				_, err := ExecNode(runtime, node)
-				if err != nil {
-					return err
-				}
+			// Every iteration starts with a new scope
+			if err := execBlock(runtime, "while", node.Body); err != nil {
+				return err
 			}
-			runtime.ExitScope()
 		default:
 			return errors.New("Condition is not a boolean expression in while loop")
 		}
-
 	}
-
-	return nil
 }
 
 func execIf(runtime *runtime.Runtime, node parser.IfNode) error {
@@ -178,13 +273,7 @@ func execIf(runtime *runtime.Runtime, node parser.IfNode) error {
 	switch val.(type) {
 	case bool:
 		if val.(bool) {
-			for _, bodynode := range node.Body {
-				_, err := ExecNode(runtime, bodynode)
-				if err != nil {
-					return err
-				}
-			}
-			return nil
+			return execBlock(runtime, "if", node.Body)
 		} else {
 			if node.NextElseBlock != nil {
 				_, err := ExecNode(runtime, node.NextElseBlock)
@@ -199,9 +288,22 @@ func execIf(runtime *runtime.Runtime, node parser.IfNode) error {
 }
 
 func execElse(runtime *runtime.Runtime, node parser.ElseNode) error {
-	for _, node := range node.Body {
-		_, err := ExecNode(runtime, node)
-		if err != nil {
+	return execBlock(runtime, "else", node.Body)
+}
+
+// Runs the statements of a `{}` block in a new scope, so the variables
+// declared in it aren't visible after the block. `kind` names the scope
+func execBlock(_runtime *runtime.Runtime, kind string, body []parser.Node) error {
+	scopeStringBuilder := strings.Builder{}
+	scopeStringBuilder.WriteString(_runtime.CurrentScope())
+	scopeStringBuilder.WriteString("$__$")
+	scopeStringBuilder.WriteString(kind)
+	_runtime.AddScope(scopeStringBuilder.String())
+	// Pop scope, also on an error
+	defer _runtime.ExitScope()
+
+	for _, node := range body {
+		if _, err := ExecNode(_runtime, node); err != nil {
 			return err
 		}
 	}
@@ -222,46 +324,75 @@ func execBinaryNotExpressionNode(runtime *runtime.Runtime, node parser.BinaryNot
 }
 
 func newFunction(_runtime *runtime.Runtime, node parser.FunctionDeclNode) (interface{}, error) {
-	_runtime.SetFunc(node.FuncName, func(runtime *runtime.Runtime, args []interface{}) (interface{}, error) {
+	params := make([]runtime.Param, 0, len(node.ArgumentNames)+1)
+	for i, name := range node.ArgumentNames {
+		params = append(params, runtime.Param{Name: name, Optional: node.Defaults != nil && node.Defaults[i] != nil})
+	}
+	if node.Rest != "" {
+		params = append(params, runtime.Param{Name: node.Rest, Variadic: true})
+	}
+
+	_runtime.SetFunc(&runtime.Function{Name: node.FuncName, Arity: len(node.ArgumentNames), Params: params, Doc: node.Doc, Call: func(r *runtime.Runtime, args []interface{}) (interface{}, error) {
+		values, given, err := runtime.BindArgs(node.FuncName, params, args)
+		if err != nil {
+			return nil, err
+		}
+
 		// Set scope
 		scopeStringBuilder := strings.Builder{}
-		scopeStringBuilder.WriteString(runtime.CurrentScope())
+		scopeStringBuilder.WriteString(r.CurrentScope())
 		scopeStringBuilder.WriteString("$")
 		scopeStringBuilder.WriteString(node.FuncName)
 		scope := scopeStringBuilder.String()
-		runtime.AddScope(scope)
-
-		// Add variables
-		for i := 0; i < len(node.ArgumentNames); i++ {
-			if i < len(args) {
-				// runtime.Vars[node.ArgumentNames[i]] = args[i]
-				runtime.SetVar(runtime.CurrentScope(), node.ArgumentNames[i], args[i])
-			} else {
-				// runtime.Vars[node.ArgumentNames[i]] = nil
-				runtime.SetVar(runtime.CurrentScope(), node.ArgumentNames[i], nil)
+		r.AddScope(scope)
+		// Pop scope, also when returning early or on an error
+		defer r.ExitScope()
+
+		// Add variables. Default values are evaluated in the function's scope, so
+		// they can use the arguments before them
+		for i, param := range params {
+			if !given[i] {
+				values[i], err = ExecNode(r, node.Defaults[i])
+				if err != nil {
+					return nil, err
+				}
 			}
+			r.SetVar(r.CurrentScope(), param.Name, values[i])
 		}
 
 		for _, node := range node.Body {
 			switch node.(type) {
 			case parser.ReturnNode:
-				return ExecNode(runtime, node)
+				return ExecNode(r, node)
 			default:
-				_, err := ExecNode(runtime, node)
+				_, err := ExecNode(r, node)
 				if err != nil {
 					return nil, err
 				}
 			}
 		}
 
-		// Pop scope
-		runtime.ExitScope()
 		return nil, nil // Function did not return any value
-	})
+	}})
 
 	return nil, nil
 }
 
+// Stores the interface in a variable with its name, so it can be used with
+// `is` and `as`
+func execInterfaceDecl(_runtime *runtime.Runtime, node parser.InterfaceDeclNode) error {
+	if err := checkDeclaration(_runtime, node.Name); err != nil {
+		return err
+	}
+
+	iface := &runtime.Interface{Name: node.Name, Doc: node.Doc}
+	for _, method := range node.Methods {
+		iface.Methods = append(iface.Methods, method.Name)
+	}
+	_runtime.SetVar(_runtime.CurrentScope(), node.Name, iface)
+	return nil
+}
+
 func execFuncCallNode(_runtime *runtime.Runtime, node parser.FunctionCallExprNode) (interface{}, error) {
 	// function := runtime.Funcs[node.FuncName]
 	function := _runtime.GetFunc(node.FuncName)
@@ -272,8 +403,10 @@ func execFuncCallNode(_runtime *runtime.Runtime, node parser.FunctionCallExprNod
 			return nil, errors.New(fmt.Sprintf("Undeclared function `%s`\n", node.FuncName))
 		} else {
 			switch variable.(type) {
+			case *runtime.Function:
+				function = variable.(*runtime.Function)
 			case func(*runtime.Runtime, []interface{}) (interface{}, error):
-				function = variable.(func(*runtime.Runtime, []interface{}) (interface{}, error))
+				function = runtime.NewFunction(node.FuncName, variable.(func(*runtime.Runtime, []interface{}) (interface{}, error)))
 			default:
 				return nil, errors.New(fmt.Sprintf("Undeclared function `%s`\n", node.FuncName))
 			}
@@ -283,43 +416,244 @@ func execFuncCallNode(_runtime *runtime.Runtime, node parser.FunctionCallExprNod
 	return execFuncCall(_runtime, function, node.Arguments, nil)
 }
 
+// Calls the function that the callee evaluates to, e.g. `handlers[0](msg)`
+func execCallExprNode(_runtime *runtime.Runtime, node parser.CallExprNode) (interface{}, error) {
+	callee, err := ExecNode(_runtime, node.Callee)
+	if err != nil {
+		return nil, err
+	}
+
+	switch callee.(type) {
+	case *runtime.Function:
+		return execFuncCall(_runtime, callee.(*runtime.Function), node.Arguments, nil)
+	case runtime.NativeFunction:
+		return execFuncCall(_runtime, runtime.NewFunction(corelib.Repr(callee), callee.(runtime.NativeFunction)), node.Arguments, nil)
+	default:
+		return nil, errors.New(fmt.Sprintf("Cannot call a value of type %s", corelib.TypeOf(callee)))
+	}
+}
+
 // In the method call, the value on the left of the method call will be the first
 // element in the argument list passed to the native function
-func execMethodCallNode(runtime *runtime.Runtime, node parser.MethodCallExprNode) (interface{}, error) {
-	calledOnValue, err := ExecNode(runtime, node.CalledOn)
+func execMethodCallNode(_runtime *runtime.Runtime, node parser.MethodCallExprNode) (interface{}, error) {
+	calledOnValue, store, err := execReceiver(_runtime, node.CalledOn)
 	if err != nil {
 		return nil, err
 	}
-	method := runtime.GetMethod(calledOnValue, node.FunctionCall.FuncName)
+	if module, isModule := calledOnValue.(*runtime.Module); isModule {
+		return execModuleCall(_runtime, module, node.FunctionCall)
+	}
+
+	method := _runtime.GetMethod(calledOnValue, node.FunctionCall.FuncName)
 	if method == nil {
 		return nil, errors.New(fmt.Sprintf("Method %s does not exist on %v\n", node.FunctionCall.FuncName, reflect.TypeOf(calledOnValue)))
 	}
-	return execFuncCall(runtime, method, node.FunctionCall.Arguments, calledOnValue)
+	methodName := corelib.TypeOf(calledOnValue) + "." + node.FunctionCall.FuncName
+	result, err := execFuncCall(_runtime, runtime.NewFunction(methodName, method), node.FunctionCall.Arguments, calledOnValue)
+	if err != nil {
+		return nil, err
+	}
+
+	root := rootVariable(node.CalledOn)
+	isConst := root != "" && _runtime.IsConst(root)
+	mutated, isMutated := result.(runtime.MutatedReceiver)
+	if !isMutated {
+		// The result can share elements with the constant (e.g. `slice`)
+		if isConst {
+			return runtime.Copy(result), nil
+		}
+		return withoutSpareCapacity(result), nil
+	}
+	if isConst {
+		return nil, errors.New(fmt.Sprintf("Cannot change constant `%s` with `%s`", root, node.FunctionCall.FuncName))
+	}
+	if err := store(mutated.Receiver, node.FunctionCall.FuncName); err != nil {
+		return nil, err
+	}
+	return withoutSpareCapacity(mutated.Result), nil
+}
+
+// Evaluates the value a method is called on. `store` puts the value changed by
+// a method (e.g. `push`) back where it came from: a variable, an element
+// (`lists[0].push(1)`) or a map field (`config.names.push("a")`). Only
+// variables keep the spare capacity of an array, see `withoutSpareCapacity`
+func execReceiver(_runtime *runtime.Runtime, node parser.Node) (value interface{}, store func(interface{}, string) error, err error) {
+	switch node.(type) {
+	case parser.VariableNode:
+		name := node.(parser.VariableNode).Name
+		value, err = _runtime.GetVar(name)
+		store = func(changed interface{}, _ string) error {
+			if exists, scope := _runtime.VarExists(name); exists {
+				_runtime.Vars[scope][name] = changed
+			}
+			return nil
+		}
+	case parser.ArrayIndexNode:
+		indexNode := node.(parser.ArrayIndexNode)
+		var collection, idx interface{}
+		if collection, err = execStored(_runtime, indexNode.Array); err != nil {
+			return nil, nil, err
+		}
+		if idx, err = ExecNode(_runtime, indexNode.Index); err != nil {
+			return nil, nil, err
+		}
+		value, err = indexValue(collection, idx)
+		store = func(changed interface{}, method string) error {
+			switch collection.(type) {
+			case []interface{}:
+				collection.([]interface{})[idx.(int64)] = withoutSpareCapacity(changed)
+			case map[string]interface{}:
+				collection.(map[string]interface{})[idx.(string)] = withoutSpareCapacity(changed)
+			default:
+				return errors.New(fmt.Sprintf("Cannot change an element of a %s with `%s`", corelib.TypeOf(collection), method))
+			}
+			return nil
+		}
+	case parser.FieldAccessNode:
+		fieldNode := node.(parser.FieldAccessNode)
+		var of interface{}
+		if of, err = execStored(_runtime, fieldNode.Of); err != nil {
+			return nil, nil, err
+		}
+		value, err = fieldValue(of, fieldNode.Name)
+		store = func(changed interface{}, method string) error {
+			m, isMap := of.(map[string]interface{})
+			if !isMap {
+				return errors.New(fmt.Sprintf("Cannot change a member of a %s with `%s`", corelib.TypeOf(of), method))
+			}
+			m[fieldNode.Name] = withoutSpareCapacity(changed)
+			return nil
+		}
+	default:
+		value, err = ExecNode(_runtime, node)
+		store = func(_ interface{}, method string) error {
+			return errors.New(fmt.Sprintf("Cannot change a temporary value with `%s`, store it in a variable first", method))
+		}
+	}
+	return value, store, err
+}
+
+// An array that is read shares its elements with the variable it came from,
+// but not the capacity after them. Appending in place (e.g. with `push`) only
+// happens on the variable itself, so it never overwrites an element of
+// another array
+func withoutSpareCapacity(value interface{}) interface{} {
+	if arr, isArray := value.([]interface{}); isArray {
+		return arr[:len(arr):len(arr)]
+	}
+	return value
+}
+
+// The variable that an element or field belongs to, e.g. `a` for `a[0].b`.
+// Empty if the value isn't stored in a variable
+func rootVariable(node parser.Node) string {
+	switch node.(type) {
+	case parser.VariableNode:
+		return node.(parser.VariableNode).Name
+	case parser.ArrayIndexNode:
+		return rootVariable(node.(parser.ArrayIndexNode).Array)
+	case parser.FieldAccessNode:
+		return rootVariable(node.(parser.FieldAccessNode).Of)
+	default:
+		return ""
+	}
+}
+
+// `module.name(args)` calls the function stored in the module
+func execModuleCall(_runtime *runtime.Runtime, module *runtime.Module, call parser.FunctionCallExprNode) (interface{}, error) {
+	member, exists := module.Members[call.FuncName]
+	if !exists {
+		return nil, errors.New(fmt.Sprintf("Module `%s` has no member `%s`", module.Name, call.FuncName))
+	}
+	function, isFunction := member.(*runtime.Function)
+	if !isFunction {
+		return nil, errors.New(fmt.Sprintf("`%s.%s` is not a function", module.Name, call.FuncName))
+	}
+	return execFuncCall(_runtime, function, call.Arguments, nil)
+}
+
+// A member of a module or the value of a map key
+func fieldValue(value interface{}, name string) (interface{}, error) {
+	switch value.(type) {
+	case *runtime.Module:
+		module := value.(*runtime.Module)
+		member, exists := module.Members[name]
+		if !exists {
+			return nil, errors.New(fmt.Sprintf("Module `%s` has no member `%s`", module.Name, name))
+		}
+		return member, nil
+	case map[string]interface{}:
+		field, exists := value.(map[string]interface{})[name]
+		if !exists {
+			return nil, errors.New(fmt.Sprintf("Map has no key `%s`", name))
+		}
+		return field, nil
+	default:
+		return nil, errors.New(fmt.Sprintf("%v has no field `%s`", reflect.TypeOf(value), name))
+	}
 }
 
 // - firstArg: Optional parameter for prepending an argument to the argument list
 //	 passed to the function (used in method call).
-func execFuncCall(runtime *runtime.Runtime, fn runtime.NativeFunction, callArgs []parser.Node, firstArg interface{}) (interface{}, error) {
+func execFuncCall(_runtime *runtime.Runtime, function *runtime.Function, callArgs []parser.Node, firstArg interface{}) (interface{}, error) {
+	if err := _runtime.Context.Err(); err != nil {
+		return nil, err
+	}
+
 	args := []interface{}{}
 	if firstArg != nil {
 		args = append(args, firstArg)
 	}
+	var named runtime.NamedArgs
 	for _, argNode := range callArgs {
-		val, err := ExecNode(runtime, argNode)
+		if namedArg, isNamed := argNode.(parser.NamedArgumentNode); isNamed {
+			if function.Params == nil && !function.TakesNamedArgs {
+				return nil, errors.New(fmt.Sprintf("`%s` doesn't take named arguments", function.Name))
+			}
+			if named == nil {
+				named = make(runtime.NamedArgs)
+			}
+			if _, exists := named[namedArg.Name]; exists {
+				return nil, errors.New(fmt.Sprintf("Argument `%s` is given more than once", namedArg.Name))
+			}
+			val, err := ExecNode(_runtime, namedArg.Value)
+			if err != nil {
+				return nil, err
+			}
+			named[namedArg.Name] = val
+			continue
+		}
+
+		val, err := ExecNode(_runtime, argNode)
 		if err != nil {
 			return nil, err
 		}
 		args = append(args, val)
 	}
+	if named != nil {
+		args = append(args, named)
+	}
+
+	return function.Call(_runtime, args)
+}
 
-	return fn(runtime, args)
+// A name can be declared once per scope. Declaring a name of an enclosing
+// scope shadows it until the current scope is exited
+func checkDeclaration(_runtime *runtime.Runtime, name string) error {
+	if _runtime.VarExistsInCurrentScope(name) {
+		return errors.New(fmt.Sprintf("Variable `%s` is already defined", name))
+	}
+	if _runtime.WarnShadowing {
+		if exists, _ := _runtime.VarExists(name); exists {
+			fmt.Fprintf(_runtime.Stderr, "Warning: `%s` shadows a variable of an enclosing scope\n", name)
+		}
+	}
+	return nil
 }
 
 func execVarDecl(runtime *runtime.Runtime, node parser.VarDeclNode) error {
-	// if _, exists := runtime.Vars[node.VarName]; exists {
-	exists, _ := runtime.VarExists(node.VarName)
-	if exists {
-		return errors.New(fmt.Sprintf("Variable `%s` is already defined", node.VarName))
+	if err := checkDeclaration(runtime, node.VarName); err != nil {
+		return err
 	}
 
 	rhs, err := ExecNode(runtime, node.Rhs)
@@ -331,12 +665,63 @@ func execVarDecl(runtime *runtime.Runtime, node parser.VarDeclNode) error {
 	return nil
 }
 
+func execConstDecl(_runtime *runtime.Runtime, node parser.ConstDeclNode) error {
+	if err := checkDeclaration(_runtime, node.Name); err != nil {
+		return err
+	}
+
+	rhs, err := ExecNode(_runtime, node.Rhs)
+	if err != nil {
+		return err
+	}
+	_runtime.SetConst(_runtime.CurrentScope(), node.Name, rhs)
+	return nil
+}
+
+func execDestructuringDecl(_runtime *runtime.Runtime, node parser.DestructuringDeclNode) error {
+	rhs, err := ExecNode(_runtime, node.Rhs)
+	if err != nil {
+		return err
+	}
+
+	var elements []interface{}
+	switch rhs.(type) {
+	case runtime.Tuple:
+		elements = rhs.(runtime.Tuple)
+	case []interface{}:
+		elements = rhs.([]interface{})
+	default:
+		return errors.New(fmt.Sprintf("Cannot destructure %v, expected a tuple or an array", rhs))
+	}
+	if len(elements) != len(node.VarNames) {
+		return errors.New(fmt.Sprintf("Cannot destructure %d values into %d names", len(elements), len(node.VarNames)))
+	}
+
+	for _, name := range node.VarNames {
+		if name == "_" {
+			continue
+		}
+		if err := checkDeclaration(_runtime, name); err != nil {
+			return err
+		}
+	}
+	for i, name := range node.VarNames {
+		if name != "_" {
+			_runtime.SetVar(_runtime.CurrentScope(), name, elements[i])
+		}
+	}
+	return nil
+}
+
 func execVarAssign(runtime *runtime.Runtime, node parser.VarAssignNode) error {
 	// if _, exists := runtime.Vars[node.VarName]; !exists {
 	exists, scope := runtime.VarExists(node.VarName)
 	if !exists {
 		return errors.New(fmt.Sprintf("Variable `%s` is not defined", node.VarName))
 	}
+	if runtime.IsConst(node.VarName) {
+		return errors.New(fmt.Sprintf("Cannot assign to constant `%s`", node.VarName))
+	}
 	rhs, err := ExecNode(runtime, node.Rhs)
 	if err != nil {
 		return err
@@ -390,10 +775,35 @@ func execBinaryExpressionNode(runtime *runtime.Runtime, node parser.BinaryExpres
 	if err != nil {
 		return nil, err
 	}
+	if node.Operator == parser.Op_Is || node.Operator == parser.Op_As {
+		return typeAssertion(runtime, left, right, node.Operator)
+	}
 	return binaryExpressionResult(left, right, node.Operator)
 }
 
+// `x is Interface` is true when x has all methods of the interface. `x as
+// Interface` returns x, or an error naming the methods it doesn't have
+func typeAssertion(_runtime *runtime.Runtime, value interface{}, rhs interface{}, op parser.Operator) (interface{}, error) {
+	iface, isInterface := rhs.(*runtime.Interface)
+	if !isInterface {
+		return nil, errors.New(fmt.Sprintf("Expected an interface after `%s`, but got a value of type %s", op, corelib.TypeOf(rhs)))
+	}
+
+	missing := _runtime.MissingMethods(value, iface)
+	if op == parser.Op_Is {
+		return len(missing) == 0, nil
+	}
+	if len(missing) != 0 {
+		return nil, errors.New(fmt.Sprintf("A value of type %s doesn't implement `%s`, it has no method `%s`", corelib.TypeOf(value), iface.Name, strings.Join(missing, "`, `")))
+	}
+	return value, nil
+}
+
 func binaryExpressionResult(lhs interface{}, rhs interface{}, op parser.Operator) (interface{}, error) {
+	if isFunction(lhs) || isFunction(rhs) {
+		return functionComparison(lhs, rhs, op)
+	}
+
 	switch lhs.(type) {
 	case int64:
 		switch rhs.(type) {
@@ -401,14 +811,8 @@ func binaryExpressionResult(lhs interface{}, rhs interface{}, op parser.Operator
 			return binaryOp(lhs.(int64), rhs.(int64), op)
 		case float64:
 			return binaryOp(float64(lhs.(int64)), rhs.(float64), op)
-		case string:
-			rhsInt, err := strconv.ParseInt(rhs.(string), 10, 64)
-			if err != nil {
-				return nil, errors.New(fmt.Sprintf("Cannot convert %s to integer in right hand side of binary expression (%v)", rhs, err))
-			}
-			return binaryOp(lhs.(int64), rhsInt, op)
 		default:
-			return nil, errors.New(fmt.Sprintf("Cannot apply binary operator to %v\n", reflect.TypeOf(rhs)))
+			return nil, operandError(lhs, rhs, op)
 		}
 	case float64:
 		switch rhs.(type) {
@@ -416,19 +820,13 @@ func binaryExpressionResult(lhs interface{}, rhs interface{}, op parser.Operator
 			return binaryOp(lhs.(float64), float64(rhs.(int64)), op)
 		case float64:
 			return binaryOp(lhs.(float64), rhs.(float64), op)
-		case string:
-			rhsFloat, err := strconv.ParseFloat(rhs.(string), 64)
-			if err != nil {
-				return nil, errors.New(fmt.Sprintf("Cannot convert %s to floating point in right hand side of binary expression (%v)", rhs, err))
-			}
-			return binaryOp(lhs.(float64), rhsFloat, op)
 		default:
-			return nil, errors.New(fmt.Sprintf("Cannot apply binary operator to %v\n", rhs))
+			return nil, operandError(lhs, rhs, op)
 		}
 	default:
 		return nil, errors.New(fmt.Sprintf("Cannot apply binary operator to %v", rhs))
 	case string:
-		rhsStr := fmt.Sprintf("%v", rhs)
+		rhsStr := corelib.Sprint(rhs)
 		switch op {
 		case parser.Op_Plus:
 			var sb strings.Builder
@@ -444,22 +842,44 @@ func binaryExpressionResult(lhs interface{}, rhs interface{}, op parser.Operator
 		}
 	case bool:
 		switch rhs.(type) {
-		case int64:
-			return binaryOpBool(lhs.(bool), rhs.(int64) == 0, op)
-		case float64:
-			return binaryOpBool(lhs.(bool), rhs.(float64) == 0, op)
-		case string:
-			rhsBool, err := strconv.ParseBool(rhs.(string))
-			if err != nil {
-				return nil, errors.New(fmt.Sprintf("Cannot convert %s to boolean in right hand side of boolean expression (%v)", rhs, err))
-			}
-			return binaryOpBool(lhs.(bool), rhsBool, op)
 		case bool:
 			return binaryOpBool(lhs.(bool), rhs.(bool), op)
 		}
 	}
 
-	return nil, errors.New(fmt.Sprintf("Cannot apply `%v` to the given operands", op))
+	return nil, operandError(lhs, rhs, op)
+}
+
+func isFunction(value interface{}) bool {
+	switch value.(type) {
+	case *runtime.Function, runtime.NativeFunction:
+		return true
+	default:
+		return false
+	}
+}
+
+// Functions are equal when they are the same function, e.g. `handler == help`.
+// A function is never equal to other values
+func functionComparison(lhs interface{}, rhs interface{}, op parser.Operator) (interface{}, error) {
+	lhsFn, _ := lhs.(*runtime.Function)
+	rhsFn, _ := rhs.(*runtime.Function)
+	same := lhsFn != nil && lhsFn == rhsFn
+
+	switch op {
+	case parser.Op_CompEqual:
+		return same, nil
+	case parser.Op_CompNEqual:
+		return !same, nil
+	default:
+		return nil, operandError(lhs, rhs, op)
+	}
+}
+
+// Numbers are never converted implicitly, e.g. `1 + "2"` is an error. Scripts
+// convert with `int`, `float`, `str` and `bool`
+func operandError(lhs interface{}, rhs interface{}, op parser.Operator) error {
+	return errors.New(fmt.Sprintf("Cannot apply `%s` to %s and %s", op, corelib.TypeOf(lhs), corelib.TypeOf(rhs)))
 }
 
 // Returns the result of a binary operation on an integer or float
@@ -6,7 +6,9 @@ import (
 	"github.com/jomy10/nootlang/corelib"
 	"github.com/jomy10/nootlang/parser"
 	"github.com/jomy10/nootlang/runtime"
+	"github.com/jomy10/nootlang/stdlib"
 	"os"
+	"reflect"
 	"testing"
 )
 
@@ -109,6 +111,49 @@ func TestDivEqual(t *testing.T) {
 	testWithOutput(`a := 4; a /= 2; noot!(a)`, "2\n", t)
 }
 
+func TestStringInterpolation(t *testing.T) {
+	testWithResult(`name := "noot"; n := 2; result := "hello {name}, you have {n + 1} {"msgs".concat("!")}"`, "hello noot, you have 3 msgs!", t)
+}
+
+// Run the source and check the value of the `result` variable
+func testWithResult(source string, expected interface{}, t *testing.T) {
+	nodes := nodes(source, t)
+
+	runtime := runtime.NewRuntime(new(bytes.Buffer), new(bytes.Buffer), os.Stdin)
+	corelib.Register(&runtime)
+	stdlib.Register(&runtime)
+
+	for _, node := range nodes {
+		if _, err := ExecNode(&runtime, node); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	val, err := runtime.GetVar("result")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(val, expected) {
+		t.Fatalf("Got %#v, but expected %#v", val, expected)
+	}
+}
+
+// Run the source and return the error it stopped with, if any
+func testWithError(source string, t *testing.T) error {
+	nodes := nodes(source, t)
+
+	runtime := runtime.NewRuntime(new(bytes.Buffer), new(bytes.Buffer), os.Stdin)
+	corelib.Register(&runtime)
+	stdlib.Register(&runtime)
+
+	for _, node := range nodes {
+		if _, err := ExecNode(&runtime, node); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // Test interpreter and check its stdout
 func testWithOutput(source string, expectedStdout string, t *testing.T) {
 	nodes := nodes(source, t)
@@ -129,3 +174,516 @@ func testWithOutput(source string, expectedStdout string, t *testing.T) {
 		t.Fatal(fmt.Sprintf("got stderr %s", bufErr.String()))
 	}
 }
+
+func TestExpressions(t *testing.T) {
+	testWithResult(
+		`def f(x) { return x * 2 }
+s := " a,b "
+result := [8 - 4 - 2, 12 / 2 / 3, f(1) + 2, -f(2), 2 * -3, s.trim().split(",")[1].upper()]`,
+		[]interface{}{int64(2), int64(2), int64(4), int64(-4), int64(-6), "B"},
+		t,
+	)
+}
+
+func TestTuples(t *testing.T) {
+	testWithResult(
+		`pair := (1, "a")
+single := (2,)
+empty := ()
+result := [pair, pair[1], single, empty, (1 + 2), pair.len(), pair.contains("a"), pair.to_array(), type_of(pair), repr(single), str(pair)]`,
+		[]interface{}{runtime.Tuple{int64(1), "a"}, "a", runtime.Tuple{int64(2)}, runtime.Tuple{}, int64(3), int64(2), true, []interface{}{int64(1), "a"}, "tuple", "(2,)", `(1, "a")`},
+		t,
+	)
+}
+
+func TestMultipleReturnValues(t *testing.T) {
+	testWithResult(
+		`def min_max(arr) {
+  return arr.min(), arr.max()
+}
+lo, hi := min_max([3, 1, 4, 1, 5])
+both := min_max([2])
+result := [lo, hi, both]`,
+		[]interface{}{int64(1), int64(5), runtime.Tuple{int64(2), int64(2)}},
+		t,
+	)
+}
+
+func TestDestructuring(t *testing.T) {
+	testWithResult(
+		`s := "a1b22"
+start, end := s.match_indices(regex("[0-9]+"))[1]
+[x, y, _] := [10, 20, 30]
+a, b := 1, "two"
+[first, _] := (true, nil)
+result := [start, end, x, y, a, b, first]`,
+		[]interface{}{int64(3), int64(5), int64(10), int64(20), int64(1), "two", true},
+		t,
+	)
+}
+
+func TestTupleErrors(t *testing.T) {
+	for _, source := range []string{
+		`t := (1, 2); t[0] = 3`,
+		`t := (1, 2); t.push(3)`,
+		`a, b := (1, 2, 3)`,
+		`[a, b] := [1]`,
+		`a, b := 5`,
+		`a := 1; a, b := 1, 2`,
+		`t := (1, 2); a := t[2]`,
+	} {
+		if err := testWithError(source, t); err == nil {
+			t.Errorf("Expected an error for %s", source)
+		}
+	}
+}
+
+func TestInterfaces(t *testing.T) {
+	testWithResult(
+		`interface Sized {
+  len()
+}
+/// Has a smallest and largest element
+interface Bounded { min(); max() }
+interface Any {}
+def size(x) {
+  return (x as Sized).len()
+}
+result := ["abc" is Sized, [1, 2] is Sized, (1, 2) is Sized, 1 is Sized, [3, 1] is Bounded, "a" is Bounded, math is Bounded, math is Sized, nil is Any, !(2 is Sized), size("noot"), type_of(Sized), str(Bounded), doc(Bounded)]`,
+		[]interface{}{true, true, true, false, true, false, true, false, true, true, int64(4), "interface", "interface Bounded", "Has a smallest and largest element"},
+		t,
+	)
+}
+
+func TestInterfaceErrors(t *testing.T) {
+	for _, source := range []string{
+		`interface Sized { len() }; x := 1 as Sized`,
+		`interface Bounded { min(); max() }; x := "a" as Bounded`,
+		`x := "a" is 1`,
+		`x := "a" as type_of`,
+		`interface Sized { len() }; interface Sized { size() }`,
+		`x := 1; interface x {}`,
+	} {
+		if err := testWithError(source, t); err == nil {
+			t.Errorf("Expected an error for %s", source)
+		}
+	}
+
+	err := testWithError(`interface Bounded { min(); max() }; x := "a" as Bounded`, t)
+	expected := "A value of type string doesn't implement `Bounded`, it has no method `min`, `max`"
+	if err == nil || err.Error() != expected {
+		t.Errorf("Got %v, but expected %s", err, expected)
+	}
+}
+
+func TestDefaultArguments(t *testing.T) {
+	testWithResult(
+		`def greet(name, greeting = "hi", end = "!") {
+  return "{greeting} {name}{end}"
+}
+def area(w, h = w) { return w * h }
+result := [greet("noot"), greet("noot", "hello"), greet("noot", end = "?"), greet(greeting = "hey", name = "pingu"), area(3), area(3, 2), type_of(value = 1)]`,
+		[]interface{}{"hi noot!", "hello noot!", "hi noot?", "hey pingu!", int64(9), int64(6), "int"},
+		t,
+	)
+}
+
+func TestVariadicArguments(t *testing.T) {
+	testWithResult(
+		`def count(...values) { return values.len() }
+def tag(name, ...values) { return name, values }
+result := [count(), count(1, 2, 3), tag("a"), tag("a", 1, 2), tag(name = "b")]`,
+		[]interface{}{int64(0), int64(3), runtime.Tuple{"a", []interface{}{}}, runtime.Tuple{"a", []interface{}{int64(1), int64(2)}}, runtime.Tuple{"b", []interface{}{}}},
+		t,
+	)
+}
+
+func TestArityErrors(t *testing.T) {
+	for source, expected := range map[string]string{
+		`def f(a, b = 1) {}; f()`:         "`f` expects 1 to 2 arguments, but got 0",
+		`def f(a, b = 1) {}; f(1, 2, 3)`:  "`f` expects 1 to 2 arguments, but got 3",
+		`def f(a, b = 1) {}; f(b = 2)`:    "`f` is missing the argument `a`",
+		`def f(a, b = 1) {}; f(1, a = 2)`: "`f` got the argument `a` twice",
+		`def f(a, b = 1) {}; f(1, c = 2)`: "`f` has no argument `c`",
+		`def f(a, ...rest) {}; f()`:       "`f` expects at least 1 argument, but got 0",
+		`def f(...rest) {}; f(rest = 1)`:  "`f` takes `...rest` as positional arguments, it can't be given by name",
+		`def f() {}; f(1)`:                "`f` expects no arguments, but got 1",
+		`def f(a, b) {}; f(1)`:            "`f` expects 2 arguments, but got 1",
+		`a := type_of(1, 2)`:              "`type_of` expects 1 argument, but got 2",
+		`a := input("a", "b")`:            "`input` expects 0 to 1 arguments, but got 2",
+		`a := str(x = 1)`:                 "`str` has no argument `x`",
+	} {
+		err := testWithError(source, t)
+		if err == nil || err.Error() != expected {
+			t.Errorf("Got %v for %s, but expected %s", err, source, expected)
+		}
+	}
+}
+
+// Native functions only take named arguments if they declare them
+func TestNamedArgumentErrors(t *testing.T) {
+	for source, expected := range map[string]string{
+		"a := [1]\na.push(x = 2)":       "`array.push` doesn't take named arguments",
+		`s := json.stringify(x = 1)`:    "`json.stringify` has no argument `x`",
+		`s := "abc".upper(x = 1)`:       "`string.upper` doesn't take named arguments",
+		`a := format("{}", 1, x = 1)`:   "`format` doesn't take named arguments",
+		`p := math.sqrt; a := p(y = 4)`: "`math.sqrt` has no argument `y`",
+		`a := math.pow(2)`:              "`math.pow` expects 2 arguments, but got 1",
+		`a := math.max()`:               "`math.max` expects at least 1 argument, but got 0",
+		`a := regex()`:                  "`regex` expects 1 argument, but got 0",
+		`a := fs.join(parts = "a")`:     "`fs.join` takes `...parts` as positional arguments, it can't be given by name",
+	} {
+		err := testWithError(source, t)
+		if err == nil || err.Error() != expected {
+			t.Errorf("Got %v for %s, but expected %s", err, source, expected)
+		}
+	}
+	testWithResult(`a := [1]; a.push(2); result := [type_of(value = a[1]), a]`, []interface{}{"int", []interface{}{int64(1), int64(2)}}, t)
+	testWithResult(`result := [math.pow(y = 3, x = 2), json.stringify([1], indent = ""), time.parse("2024-05-06", layout = time.date).day()]`, []interface{}{int64(8), "[\n1\n]", int64(6)}, t)
+}
+
+func TestFunctionValues(t *testing.T) {
+	testWithResult(
+		`def help(msg) { return "help " + msg }
+def ping(msg) { return "pong" }
+def get_handler() { return ping }
+handlers := [help, ping]
+table := json.parse("{}")
+table["help"] = help
+h := handlers[0]
+result := [handlers[0]("me"), get_handler()("x"), table["help"]("a"), h("b"), h == help, h != ping, help == ping, handlers[1] == get_handler(), help == 1, h == nil, str(help), str([help, 1]), "{ping}", type_of(get_handler())]`,
+		[]interface{}{"help me", "pong", "help a", "help b", true, true, false, true, false, false, "<fn help/1>", "[<fn help/1> 1]", "<fn ping/1>", "function"},
+		t,
+	)
+}
+
+func TestCallStatements(t *testing.T) {
+	testWithOutput(
+		`def hi() { noot!("hi") }
+def get() { return hi }
+fs := [hi]
+fs[0]()
+get()()
+noot!(hi)`,
+		"hi\nhi\n<fn hi/0>\n",
+		t,
+	)
+}
+
+func TestFunctionValueErrors(t *testing.T) {
+	for _, source := range []string{
+		`a := [1]; a[0]()`,
+		`def f() { return 1 }; a := f()()`,
+		`def f() {}; a := f < f`,
+		`def f() {}; a := f + 1`,
+	} {
+		if err := testWithError(source, t); err == nil {
+			t.Errorf("Expected an error for %s", source)
+		}
+	}
+}
+
+func TestConstants(t *testing.T) {
+	testWithResult(
+		`const MAX = 3
+const NAMES = ["a", "b"]
+def limit(x) {
+  return math.min(x, MAX)
+}
+names := NAMES
+names.push("c")
+result := [limit(5), limit(2), NAMES, names]`,
+		[]interface{}{int64(3), int64(2), []interface{}{"a", "b"}, []interface{}{"a", "b", "c"}},
+		t,
+	)
+}
+
+func TestConstantErrors(t *testing.T) {
+	for source, expected := range map[string]string{
+		"def f() { X = 2 }\nconst X = 1\nf()":      "Cannot assign to constant `X`",
+		"def f() { X[0] = 2 }\nconst X = [1]\nf()": "Cannot assign to an element of constant `X`",
+		"const X = [1]\nX.push(2)":                 "Cannot change constant `X` with `push`",
+		"const X = 1\nconst X = 2":                 "Variable `X` is already defined",
+		"const X = 1\nX := 2":                      "Variable `X` is already defined",
+		"math = 1":                                 "Cannot assign to constant `math`",
+	} {
+		err := testWithError(source, t)
+		if err == nil || err.Error() != expected {
+			t.Errorf("Got %v for %s, but expected %s", err, source, expected)
+		}
+	}
+}
+
+// Modules are constants of the scope enclosing the global scope, so scripts can
+// declare variables with their names
+func TestShadowedModules(t *testing.T) {
+	testWithResult(
+		`one := math.abs(-1)
+time := 5
+json := json.parse("[1]")
+math := 2
+const fs = "files"
+result := [time, json, math, fs, one]`,
+		[]interface{}{int64(5), []interface{}{int64(1)}, int64(2), "files", int64(1)},
+		t,
+	)
+	if err := testWithError("math := 1\nmath := 2", t); err == nil {
+		t.Error("Expected an error for declaring `math` twice")
+	}
+}
+
+func TestHostConstants(t *testing.T) {
+	r := runtime.NewRuntime(new(bytes.Buffer), new(bytes.Buffer), os.Stdin)
+	corelib.Register(&r)
+	r.SetConst("GLOBAL", "config", map[string]interface{}{"prefix": "!"})
+
+	for _, source := range []string{`config["prefix"] = "?"`, `config = nil`} {
+		for _, node := range nodes(source, t) {
+			if _, err := ExecNode(&r, node); err == nil {
+				t.Errorf("Expected an error for %s", source)
+			}
+		}
+	}
+	for _, node := range nodes(`prefix := config["prefix"]`, t) {
+		if _, err := ExecNode(&r, node); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if prefix, _ := r.GetVar("prefix"); prefix != "!" {
+		t.Errorf("Got %v, but expected !", prefix)
+	}
+}
+
+// A constant can't be changed through a variable or parameter holding it
+func TestConstantAliases(t *testing.T) {
+	testWithResult(
+		`const P = [1, 2]
+x := P
+x[0] = 99
+x.push(3)
+nested := [P]
+const CFG = json.parse("\{\"a\": 1, \"list\": [1]\}")
+def f(m) {
+  m["a"] = 2
+  list := m["list"]
+  list.push(2)
+  m["list"] = list
+  return m
+}
+changed := f(CFG)
+const GRID = [[1, 2], [3]]
+row := GRID[0]
+row[0] = 9
+part := GRID.slice(1)
+first := part[0]
+first[0] = 9
+result := [P, x, CFG, changed["a"], changed["list"], GRID]`,
+		[]interface{}{
+			[]interface{}{int64(1), int64(2)},
+			[]interface{}{int64(99), int64(2), int64(3)},
+			map[string]interface{}{"a": int64(1), "list": []interface{}{int64(1)}},
+			int64(2),
+			[]interface{}{int64(1), int64(2)},
+			[]interface{}{[]interface{}{int64(1), int64(2)}, []interface{}{int64(3)}},
+		},
+		t,
+	)
+}
+
+// Variables holding the same array or map share its elements, `push` only
+// changes the variable it is called on
+func TestSharedValues(t *testing.T) {
+	testWithResult(
+		`a := [1, [2]]
+b := a
+b[0] = 9
+inner := b[1]
+inner.push(3)
+m := json.parse("{}")
+m["list"] = a
+a[0] = 5
+def change(arr) { arr[0] = 0 }
+change(a)
+pushed := []
+pushed.push(a)
+a[0] = 6
+result := [a, b, inner, m["list"], pushed]`,
+		[]interface{}{
+			[]interface{}{int64(6), []interface{}{int64(2)}},
+			[]interface{}{int64(6), []interface{}{int64(2)}},
+			[]interface{}{int64(2), int64(3)},
+			[]interface{}{int64(6), []interface{}{int64(2)}},
+			[]interface{}{[]interface{}{int64(6), []interface{}{int64(2)}}},
+		},
+		t,
+	)
+}
+
+// A host value that contains itself is copied into one that contains itself
+func TestCopyCycles(t *testing.T) {
+	r := runtime.NewRuntime(new(bytes.Buffer), new(bytes.Buffer), os.Stdin)
+	corelib.Register(&r)
+	cfg := map[string]interface{}{"a": int64(1)}
+	cfg["self"] = cfg
+	r.SetConst("GLOBAL", "cfg", cfg)
+
+	for _, node := range nodes(`c := cfg`, t) {
+		if _, err := ExecNode(&r, node); err != nil {
+			t.Fatal(err)
+		}
+	}
+	c, _ := r.GetVar("c")
+	copied := c.(map[string]interface{})
+	self := copied["self"].(map[string]interface{})
+	if reflect.ValueOf(self).Pointer() != reflect.ValueOf(copied).Pointer() || reflect.ValueOf(self).Pointer() == reflect.ValueOf(cfg).Pointer() {
+		t.Errorf("Expected the copy to contain itself")
+	}
+}
+
+func TestBlockScopes(t *testing.T) {
+	testWithResult(
+		`total := 0
+flag := false
+if total == 0 {
+  a := 1
+  total = a
+} else {
+  a := 2
+  total = a
+}
+if flag {
+  b := "if"
+} elsif total == 1 {
+  b := "elsif"
+  if true {
+    c := b + "!"
+    flag = c.len() == 6
+  }
+} else {
+  b := "else"
+}
+i := 0
+squares := []
+while i < 3 {
+  square := i * i
+  squares.push(square)
+  i += 1
+}
+result := [total, flag, i, squares]`,
+		[]interface{}{int64(1), true, int64(3), []interface{}{int64(0), int64(1), int64(4)}},
+		t,
+	)
+}
+
+func TestBlockScopeErrors(t *testing.T) {
+	for source, expected := range map[string]string{
+		"if true { a := 1 }\nb := a":                     "Variable a is not declared",
+		"i := 0\nwhile i < 1 { a := i; i += 1 }\nb := a": "Variable a is not declared",
+		"if false {} else { a := 1 }\nb := a":            "Variable a is not declared",
+		"x := 1\nif true { x := 2 }\nx := 3":             "Variable `x` is already defined",
+	} {
+		err := testWithError(source, t)
+		if err == nil || err.Error() != expected {
+			t.Errorf("Got %v for %s, but expected %s", err, source, expected)
+		}
+	}
+}
+
+// The example of docs/README.md: a declaration in a function shadows the global
+func TestShadowing(t *testing.T) {
+	testWithOutput(
+		`var1 := 5
+def my_func() {
+  noot!(var1) // global scope
+  var1 := 6
+  noot!(var1) // function scope
+}
+
+my_func()
+my_func()
+noot!(var1)`,
+		"5\n6\n5\n6\n5\n",
+		t,
+	)
+	testWithOutput(
+		`x := 1
+const Y = 1
+if true {
+  x := 2
+  Y := 2
+  Y = 3
+  noot!(x, Y)
+}
+a, b := x, Y
+def f() {
+  a, b := "a", "b"
+  noot!(a, b)
+}
+f()
+noot!(a, b)`,
+		"2 3\na b\n1 1\n",
+		t,
+	)
+}
+
+// Assigning an element changes the closest variable, not the ones it shadows
+func TestShadowedIndexAssignment(t *testing.T) {
+	testWithResult(
+		`a := [1]
+def f() {
+  a := [1, 2, 3]
+  a[2] = 5
+  return a
+}
+b := "s"
+if true {
+  b := [1, 2]
+  b[0] = 9
+  a[0] = b[0]
+}
+result := [a, f(), b]`,
+		[]interface{}{[]interface{}{int64(9)}, []interface{}{int64(1), int64(2), int64(5)}, "s"},
+		t,
+	)
+}
+
+func TestIndexAssignmentErrors(t *testing.T) {
+	for source, expected := range map[string]string{
+		"a := \"s\"\na[0] = 1": "Cannot assign to an element of `a`, it isn't an array",
+		"a := [1]\na[1] = 2":   "Index 1 is out of range, the length is 1",
+		"a := [1]\na[-1] = 2":  "Index -1 is out of range, the length is 1",
+		"a := [1, 2, 3]\ndef f() { a := [1]\na[2] = 5 }\nf()": "Index 2 is out of range, the length is 1",
+	} {
+		err := testWithError(source, t)
+		if err == nil || err.Error() != expected {
+			t.Errorf("Got %v for %s, but expected %s", err, source, expected)
+		}
+	}
+}
+
+func TestShadowingWarning(t *testing.T) {
+	source := `x := 1
+def f() { x := 2 }
+f()
+if true { y := 1 }
+y := 2`
+	for _, warn := range []bool{false, true} {
+		stderr := new(bytes.Buffer)
+		r := runtime.NewRuntime(new(bytes.Buffer), stderr, os.Stdin)
+		r.WarnShadowing = warn
+		corelib.Register(&r)
+		for _, node := range nodes(source, t) {
+			if _, err := ExecNode(&r, node); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		expected := ""
+		if warn {
+			expected = "Warning: `x` shadows a variable of an enclosing scope\n"
+		}
+		if stderr.String() != expected {
+			t.Errorf("Got stderr '%s' with WarnShadowing %v, but expected '%s'", stderr.String(), warn, expected)
+		}
+	}
+}
@@ -0,0 +1,572 @@
+package interpreter
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io/fs"
+	"math"
+	"math/rand"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/jomy10/nootlang/corelib"
+	"github.com/jomy10/nootlang/runtime"
+	"github.com/jomy10/nootlang/stdlib"
+)
+
+func TestMath(t *testing.T) {
+	testWithResult(
+		`result := [math.abs(-3), math.abs(-1.5), math.min(3, 1.5, 2), math.max(1, 4), math.pow(2, 10), math.pow(4, 0.5), math.sqrt(16), math.floor(1.7), math.ceil(1.2), math.round(2.5), math.gcd(12, 18), math.lcm(4, 6, 10), math.clamp(12, 0, 10), math.clamp(-2, 0, 10)]`,
+		[]interface{}{int64(3), 1.5, 1.5, int64(4), int64(1024), 2.0, 4.0, int64(1), int64(2), int64(3), int64(6), int64(60), int64(10), int64(0)},
+		t,
+	)
+}
+
+func TestMathConstants(t *testing.T) {
+	testWithResult(
+		`result := [math.round(math.pi * 100), math.round(math.e * 100), math.sin(0.0), math.cos(0), math.atan2(0, 1)]`,
+		[]interface{}{int64(314), int64(272), 0.0, 1.0, 0.0},
+		t,
+	)
+}
+
+// Integer powers are exact
+func TestMathIntegerPow(t *testing.T) {
+	testWithResult(
+		`result := [math.pow(3, 39), math.pow(-2, 63), math.pow(-1, 9223372036854775807), math.pow(1, 9223372036854775807), math.pow(0, 0)]`,
+		[]interface{}{int64(4052555153018976267), int64(math.MinInt64), int64(-1), int64(1), int64(1)},
+		t,
+	)
+}
+
+// Results that need the absolute value of the smallest integer are errors,
+// other results involving it are exact
+func TestMathSmallestInteger(t *testing.T) {
+	testWithResult(
+		`min := -9223372036854775807 - 1
+result := [math.gcd(min, 6), math.gcd(6, min), math.lcm(min, 1, 0), math.floor(-9200000000000000000.5), math.round(-9223372036854775808.0)]`,
+		[]interface{}{int64(2), int64(2), int64(0), int64(-9200000000000000000), int64(math.MinInt64)},
+		t,
+	)
+}
+
+// Large integers are compared exactly, not as floats
+func TestMathMinMaxIntegers(t *testing.T) {
+	testWithResult(
+		`result := [math.min(9223372036854775807, 9223372036854775806), math.max(9223372036854775806, 9223372036854775807), math.max(2, 2.5), math.min(2, 2.0)]`,
+		[]interface{}{int64(9223372036854775806), int64(9223372036854775807), 2.5, int64(2)},
+		t,
+	)
+}
+
+func TestMathErrors(t *testing.T) {
+	for _, source := range []string{
+		`a := math.sqrt("4")`,
+		`a := math.gcd(1.5, 2)`,
+		`a := math.clamp(1, 10, 0)`,
+		`a := math.choice([])`,
+		`a := math.nope(1)`,
+		`a := math.nope`,
+		`a := math.pow(2, 63)`,
+		`a := math.pow(-3, 40)`,
+		`a := math.pow(10, 9223372036854775807)`,
+		`a := math.max(1, "2")`,
+		`a := math.abs(-9223372036854775807 - 1)`,
+		`a := math.gcd(-9223372036854775807 - 1)`,
+		`a := math.gcd(-9223372036854775807 - 1, 0)`,
+		`a := math.lcm(-9223372036854775807 - 1, 3)`,
+		`a := math.lcm(9223372036854775807, 2)`,
+		`a := math.floor(10000000000000000000.0)`,
+		`a := math.ceil(-10000000000000000000.0)`,
+		`a := math.round(9223372036854775807.0)`,
+		`a := math.round(0.0 / 0.0)`,
+	} {
+		if err := testWithError(source, t); err == nil {
+			t.Errorf("Expected an error for %s", source)
+		}
+	}
+}
+
+// Ranges wider than the largest integer don't overflow
+func TestMathRandIntRange(t *testing.T) {
+	for seed := int64(0); seed < 20; seed++ {
+		r := runtime.NewRuntime(new(bytes.Buffer), new(bytes.Buffer), os.Stdin)
+		stdlib.Register(&r)
+		r.Rand = rand.New(rand.NewSource(seed))
+		source := `min := -9223372036854775807 - 1
+max := 9223372036854775807
+result := [math.rand_int(0, max), math.rand_int(min, max), math.rand_int(min, -1), math.rand_int(-1, max), math.rand_int(min, min)]`
+		for _, node := range nodes(source, t) {
+			if _, err := ExecNode(&r, node); err != nil {
+				t.Fatal(err)
+			}
+		}
+		result, _ := r.GetVar("result")
+		values := result.([]interface{})
+		if values[0].(int64) < 0 || values[2].(int64) >= 0 || values[3].(int64) < -1 || values[4].(int64) != math.MinInt64 {
+			t.Errorf("Got %v for seed %d", values, seed)
+		}
+	}
+}
+
+// The host sets the seed, so the same seed gives the same random numbers
+func TestMathRandomSeed(t *testing.T) {
+	source := `result := [math.rand_int(1, 6), math.rand_int(1, 6), math.rand_float(), math.shuffle([1, 2, 3, 4]), math.choice(["a", "b"])]`
+
+	run := func(seed int64) interface{} {
+		r := runtime.NewRuntime(new(bytes.Buffer), new(bytes.Buffer), os.Stdin)
+		stdlib.Register(&r)
+		r.Rand = rand.New(rand.NewSource(seed))
+		for _, node := range nodes(source, t) {
+			if _, err := ExecNode(&r, node); err != nil {
+				t.Fatal(err)
+			}
+		}
+		result, _ := r.GetVar("result")
+		return result
+	}
+
+	first := run(42)
+	if !reflect.DeepEqual(first, run(42)) {
+		t.Fatalf("Got different results for the same seed")
+	}
+
+	values := first.([]interface{})
+	for _, n := range values[:2] {
+		if n.(int64) < 1 || n.(int64) > 6 {
+			t.Errorf("rand_int(1, 6) returned %v", n)
+		}
+	}
+	if f := values[2].(float64); f < 0 || f >= 1 {
+		t.Errorf("rand_float() returned %v", f)
+	}
+	if len(values[3].([]interface{})) != 4 {
+		t.Errorf("shuffle returned %v", values[3])
+	}
+}
+
+func TestJsonParse(t *testing.T) {
+	testWithResult(
+		"obj := json.parse(`{\"name\": \"noot\", \"n\": 3, \"ratio\": 1.5, \"big\": 1e3, \"tags\": [\"a\", null, true]}`)\n"+
+			`obj["n"] = obj.n + 1
+result := [obj.name, obj["n"], obj.ratio, obj.big, obj.tags, obj["missing"], obj.keys(), obj.has("n")]`,
+		[]interface{}{"noot", int64(4), 1.5, 1000.0, []interface{}{"a", nil, true}, nil, []interface{}{"big", "n", "name", "ratio", "tags"}, true},
+		t,
+	)
+}
+
+func TestJsonStringify(t *testing.T) {
+	testWithResult(
+		"obj := json.parse(`{\"b\": [1, 2.0], \"a\": \"x<y\"}`)\n"+
+			`result := [json.stringify(obj), json.stringify(obj, 2), json.stringify([nil, false, 0.5])]`,
+		[]interface{}{
+			`{"a":"x<y","b":[1,2.0]}`,
+			"{\n  \"a\": \"x<y\",\n  \"b\": [\n    1,\n    2.0\n  ]\n}",
+			`[null,false,0.5]`,
+		},
+		t,
+	)
+}
+
+func TestJsonErrors(t *testing.T) {
+	tests := map[string]string{
+		"{\n  \"a\": 1,\n  \"b\": x\n}": "line 3, column 8 (offset 19)",
+		`[1, 2`:                         "unexpected end of input at line 1, column 6 (offset 5)",
+		`{} {}`:                         "unexpected data after the value at line 1, column 4 (offset 3)",
+	}
+	for input, position := range tests {
+		r := runtime.NewRuntime(new(bytes.Buffer), new(bytes.Buffer), os.Stdin)
+		stdlib.Register(&r)
+		json, _ := r.GetVar("json")
+		parse := json.(*runtime.Module).Members["parse"].(*runtime.Function)
+
+		_, err := parse.Call(&r, []interface{}{input})
+		if err == nil || !strings.HasSuffix(err.Error(), position) {
+			t.Errorf("Parsing %q gave error %v, but expected it at %s", input, err, position)
+		}
+	}
+
+	if err := testWithError(`a := json.stringify(math.sqrt)`, t); err == nil {
+		t.Errorf("Expected an error for stringifying a function")
+	}
+	expected := "`json.stringify` expects an indent of 0 or more, but got -2"
+	if err := testWithError(`a := json.stringify([1], -2)`, t); err == nil || err.Error() != expected {
+		t.Errorf("Got %v, but expected %s", err, expected)
+	}
+}
+
+// A host value that contains itself can't be written out
+func TestJsonCycles(t *testing.T) {
+	stdout := new(bytes.Buffer)
+	r := runtime.NewRuntime(stdout, new(bytes.Buffer), os.Stdin)
+	corelib.Register(&r)
+	stdlib.Register(&r)
+	m := map[string]interface{}{"a": int64(1)}
+	m["list"] = []interface{}{m}
+	r.SetVar("GLOBAL", "m", m)
+
+	for source, expected := range map[string]string{
+		`s := json.stringify(m)`: "`json.stringify` cannot convert a value that contains itself",
+		`s := repr(m)`:           "`repr` cannot show a value that contains itself",
+	} {
+		for _, node := range nodes(source, t) {
+			if _, err := ExecNode(&r, node); err == nil || err.Error() != expected {
+				t.Errorf("Got %v for %s, but expected %s", err, source, expected)
+			}
+		}
+	}
+	for _, node := range nodes(`noot!(m)`, t) {
+		if _, err := ExecNode(&r, node); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if stdout.String() != "<value that contains itself>\n" {
+		t.Errorf("Got %q", stdout.String())
+	}
+}
+
+func TestRegex(t *testing.T) {
+	testWithResult(
+		"re := regex(`(?P<key>\\w+)=(\\d+)`)\n"+
+			`s := "a=1, b=22"
+caps := re.captures(s)
+result := [re.match(s), re.match("nope"), re.find(s), re.find("nope"), re.find_all(s), re.find_all(s, 1), re.replace(s, "$2:$\{key\}"), caps["key"], caps["2"], re.captures("nope")]`,
+		[]interface{}{true, false, "a=1", nil, []interface{}{"a=1", "b=22"}, []interface{}{"a=1"}, "1:a, 22:b", "a", "1", nil},
+		t,
+	)
+}
+
+func TestRegexInStringMethods(t *testing.T) {
+	testWithResult(
+		"re := regex(`\\s*,\\s*`)\n"+
+			`s := "a , b,c"
+result := [s.split(re), s.replace(re, ";"), s.contains(re), s.match_indices(re), s.submatch(re), regex("[0-9]+").split("a1b22c"), repr(re)]`,
+		[]interface{}{
+			[]interface{}{"a", "b", "c"},
+			"a;b;c",
+			true,
+			[]interface{}{runtime.Tuple{int64(1), int64(4)}, runtime.Tuple{int64(5), int64(6)}},
+			[]interface{}{[]interface{}{" , "}, []interface{}{","}},
+			[]interface{}{"a", "b", "c"},
+			"regex(\"\\\\s*,\\\\s*\")",
+		},
+		t,
+	)
+}
+
+// Indexes are counted in characters, like in the other string methods
+func TestMatchIndicesNonAscii(t *testing.T) {
+	testWithResult(
+		`result := ["héllo 42".match_indices("[0-9]+"), "héé ünï".match_indices("[éï]+"), "日本".match_indices("")]`,
+		[]interface{}{
+			[]interface{}{runtime.Tuple{int64(6), int64(8)}},
+			[]interface{}{runtime.Tuple{int64(1), int64(3)}, runtime.Tuple{int64(6), int64(7)}},
+			[]interface{}{runtime.Tuple{int64(0), int64(0)}, runtime.Tuple{int64(1), int64(1)}, runtime.Tuple{int64(2), int64(2)}},
+		},
+		t,
+	)
+}
+
+func TestRegexErrors(t *testing.T) {
+	for _, source := range []string{
+		`re := regex("(")`,
+		`re := regex("a"); b := re.match(1)`,
+		`re := regex("a"); b := "aa".replace(re, "b", 1)`,
+	} {
+		if err := testWithError(source, t); err == nil {
+			t.Errorf("Expected an error for %s", source)
+		}
+	}
+}
+
+// A clock that stands still until something sleeps
+type frozenClock struct {
+	now time.Time
+}
+
+func (c *frozenClock) Now() time.Time {
+	return c.now
+}
+
+func (c *frozenClock) Sleep(ctx context.Context, duration time.Duration) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	c.now = c.now.Add(duration)
+	return nil
+}
+
+func runWithClock(source string, clock runtime.Clock, t *testing.T) interface{} {
+	r := runtime.NewRuntime(new(bytes.Buffer), new(bytes.Buffer), os.Stdin)
+	stdlib.Register(&r)
+	r.Clock = clock
+	for _, node := range nodes(source, t) {
+		if _, err := ExecNode(&r, node); err != nil {
+			t.Fatal(err)
+		}
+	}
+	result, _ := r.GetVar("result")
+	return result
+}
+
+func TestTimeFrozenClock(t *testing.T) {
+	clock := &frozenClock{time.Date(2024, time.March, 9, 14, 30, 5, 0, time.UTC)}
+	result := runWithClock(`start := time.now()
+time.sleep(time.minutes(2))
+time.sleep(1.5)
+result := [time.unix(), time.since(start).seconds(), start.format(time.datetime), start.weekday(), time.now().minute()]`, clock, t)
+
+	expected := []interface{}{int64(1709994726), 121.5, "2024-03-09 14:30:05", "Saturday", int64(32)}
+	if !reflect.DeepEqual(result, expected) {
+		t.Fatalf("Got %#v, but expected %#v", result, expected)
+	}
+}
+
+func TestTimeParseAndFormat(t *testing.T) {
+	testWithResult(
+		`t := time.parse("2023-12-31 23:59:30", time.datetime)
+later := t.add(time.seconds(45))
+result := [later.year(), later.month(), later.day(), later.format(time.clock), later.format(), t.unix(), time.from_unix(0).format(time.date), later.sub(t).seconds(), t.before(later), t.after(later), time.parse("2023-12-31T23:59:30Z").equal(t)]`,
+		[]interface{}{int64(2024), int64(1), int64(1), "00:00:15", "2024-01-01T00:00:15Z", int64(1704067170), "1970-01-01", 45.0, true, false, true},
+		t,
+	)
+}
+
+func TestDurations(t *testing.T) {
+	testWithResult(
+		`d := time.duration("1h30m")
+result := [d.minutes(), d.add(time.minutes(15)).hours(), d.sub(time.hours(1)).string(), time.milliseconds(1500).seconds(), time.seconds(0.25).milliseconds(), d.mul(2).hours(), time.minutes(1).mul(0.5).string()]`,
+		[]interface{}{90.0, 1.75, "30m0s", 1.5, int64(250), 3.0, "30s"},
+		t,
+	)
+}
+
+func TestTimeErrors(t *testing.T) {
+	for _, source := range []string{
+		`t := time.parse("yesterday")`,
+		`d := time.duration("soon")`,
+		`time.sleep(-1)`,
+		`time.sleep("1s")`,
+		`t := time.now().add(5)`,
+		`d := time.seconds(1).add(1)`,
+	} {
+		if err := testWithError(source, t); err == nil {
+			t.Errorf("Expected an error for %s", source)
+		}
+	}
+}
+
+// Cancelling the context interrupts a sleep and stops loops
+func TestTimeSleepCancelled(t *testing.T) {
+	for _, source := range []string{
+		`time.sleep(time.hours(1))`,
+		"while true {\n}",
+	} {
+		ctx, cancel := context.WithCancel(context.Background())
+		go func() {
+			time.Sleep(10 * time.Millisecond)
+			cancel()
+		}()
+
+		done := make(chan error)
+		go func() {
+			done <- InterpretContext(ctx, nodes(source, t), new(bytes.Buffer), new(bytes.Buffer), os.Stdin, []func(*runtime.Runtime){stdlib.Register})
+		}()
+
+		select {
+		case err := <-done:
+			if !errors.Is(err, context.Canceled) {
+				t.Errorf("Expected the script to be cancelled, but got %v for %s", err, source)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("Cancelling didn't stop %s", source)
+		}
+	}
+}
+
+// An in-memory file system
+type memFS struct {
+	fstest.MapFS
+}
+
+func (m memFS) WriteFile(name string, data []byte) error {
+	m.MapFS[name] = &fstest.MapFile{Data: data}
+	return nil
+}
+
+func (m memFS) AppendFile(name string, data []byte) error {
+	file, ok := m.MapFS[name]
+	if !ok {
+		return m.WriteFile(name, data)
+	}
+	file.Data = append(file.Data, data...)
+	return nil
+}
+
+func (m memFS) MkdirAll(name string) error {
+	m.MapFS[name] = &fstest.MapFile{Mode: fs.ModeDir}
+	return nil
+}
+
+func (m memFS) Remove(name string) error {
+	if _, ok := m.MapFS[name]; !ok {
+		return &fs.PathError{Op: "remove", Path: name, Err: fs.ErrNotExist}
+	}
+	delete(m.MapFS, name)
+	return nil
+}
+
+func runWithFS(source string, fsys runtime.FileSystem, t *testing.T) (interface{}, error) {
+	r := runtime.NewRuntime(new(bytes.Buffer), new(bytes.Buffer), os.Stdin)
+	corelib.Register(&r)
+	stdlib.Register(&r)
+	r.FS = fsys
+	for _, node := range nodes(source, t) {
+		if _, err := ExecNode(&r, node); err != nil {
+			return nil, err
+		}
+	}
+	result, _ := r.GetVar("result")
+	return result, nil
+}
+
+func TestFs(t *testing.T) {
+	fsys := memFS{fstest.MapFS{
+		"notes/a.txt": {Data: []byte("one\r\ntwo\n")},
+		"notes/b.md":  {Data: []byte("# b")},
+		"config.json": {Data: []byte("{}")},
+		"empty.txt":   {Data: []byte("")},
+	}}
+	result, err := runWithFS(`fs.write_file("notes/c.txt", "hello")
+fs.append_file("notes/c.txt", " world")
+fs.append_file("log.txt", "started")
+fs.mkdir("out")
+fs.remove("config.json")
+result := [fs.read_lines("notes/a.txt"), fs.read_lines("empty.txt"), read_to_string("notes/c.txt"), fs.read_to_string("log.txt"), fs.exists("notes"), fs.exists("config.json"), fs.list_dir("notes"), fs.glob("notes/*.txt"), fs.exists("out")]`, fsys, t)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []interface{}{
+		[]interface{}{"one", "two"},
+		[]interface{}{},
+		"hello world",
+		"started",
+		true,
+		false,
+		[]interface{}{"a.txt", "b.md", "c.txt"},
+		[]interface{}{"notes/a.txt", "notes/c.txt"},
+		true,
+	}
+	if !reflect.DeepEqual(result, expected) {
+		t.Fatalf("Got %#v, but expected %#v", result, expected)
+	}
+}
+
+func TestFsPaths(t *testing.T) {
+	testWithResult(
+		`result := [fs.join("a", "b", "c.txt"), fs.join("a/", "../b"), fs.basename("dir/file.tar.gz"), fs.dirname("dir/file.txt"), fs.ext("dir/file.tar.gz"), fs.ext("README")]`,
+		[]interface{}{"a/b/c.txt", "b", "file.tar.gz", "dir", ".gz", ""},
+		t,
+	)
+}
+
+func TestFsErrors(t *testing.T) {
+	fsys := memFS{fstest.MapFS{}}
+	for _, source := range []string{
+		`a := fs.read_lines("missing.txt")`,
+		`a := read_to_string("missing.txt")`,
+		`fs.remove("missing.txt")`,
+		`fs.write_file("a.txt", 5)`,
+		`a := fs.list_dir(1)`,
+		`a := fs.join("a", 1)`,
+	} {
+		if _, err := runWithFS(source, fsys, t); err == nil {
+			t.Errorf("Expected an error for %s", source)
+		}
+	}
+}
+
+// The default file system takes OS paths, including absolute ones
+func TestFsOS(t *testing.T) {
+	dir := t.TempDir()
+	result, err := runWithFS(`dir := `+"`"+dir+"`"+`
+fs.mkdir(fs.join(dir, "sub", "deeper"))
+fs.write_file(fs.join(dir, "sub", "a.txt"), "a")
+fs.append_file(fs.join(dir, "sub", "a.txt"), "\nb")
+result := [fs.read_lines(fs.join(dir, "sub", "a.txt")), fs.list_dir(fs.join(dir, "sub")), fs.glob(fs.join(dir, "*", "*.txt")).len()]`, runtime.OSFileSystem{}, t)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []interface{}{[]interface{}{"a", "b"}, []interface{}{"a.txt", "deeper"}, int64(1)}
+	if !reflect.DeepEqual(result, expected) {
+		t.Fatalf("Got %#v, but expected %#v", result, expected)
+	}
+}
+
+func runWithStdin(source string, stdin string, t *testing.T) (interface{}, string) {
+	stdout := new(bytes.Buffer)
+	r := runtime.NewRuntime(stdout, new(bytes.Buffer), bytes.NewBufferString(stdin))
+	corelib.Register(&r)
+	stdlib.Register(&r)
+	for _, node := range nodes(source, t) {
+		if _, err := ExecNode(&r, node); err != nil {
+			t.Fatal(err)
+		}
+	}
+	result, _ := r.GetVar("result")
+	return result, stdout.String()
+}
+
+func TestInput(t *testing.T) {
+	result, stdout := runWithStdin(
+		`name := input("name? ")
+result := [name, read_line(), lines(), read_line(), read_all()]`,
+		"noot\r\nsecond\nthird\nlast",
+		t,
+	)
+
+	expected := []interface{}{"noot", "second", []interface{}{"third", "last"}, nil, ""}
+	if !reflect.DeepEqual(result, expected) {
+		t.Fatalf("Got %#v, but expected %#v", result, expected)
+	}
+	if stdout != "name? " {
+		t.Fatalf("Got stdout %q", stdout)
+	}
+}
+
+// Reading a line doesn't lose the input buffered after it
+func TestReadAll(t *testing.T) {
+	result, _ := runWithStdin(
+		`first := read_line()
+result := [first, read_all(), lines(), input()]`,
+		"a\nb\nc\n",
+		t,
+	)
+
+	expected := []interface{}{"a", "b\nc\n", []interface{}{}, nil}
+	if !reflect.DeepEqual(result, expected) {
+		t.Fatalf("Got %#v, but expected %#v", result, expected)
+	}
+}
+
+func TestInputErrors(t *testing.T) {
+	for _, source := range []string{
+		`a := input(1)`,
+		`a := input("a", "b")`,
+		`a := read_line(1)`,
+		`a := read_all(1)`,
+		`a := lines(1)`,
+		`a := lines(keep_ends = true)`,
+	} {
+		if err := testWithError(source, t); err == nil {
+			t.Errorf("Expected an error for %s", source)
+		}
+	}
+}
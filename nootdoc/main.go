@@ -0,0 +1,75 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/jomy10/nootlang/parser"
+)
+
+// Generates markdown documentation for the functions and interfaces declared in
+// noot files, using the `///` comments above them
+//
+//	nootdoc file.noot [other.noot ...] > docs.md
+func main() {
+	if len(os.Args) < 2 {
+		os.Stderr.WriteString("usage: nootdoc file.noot [other.noot ...]\n")
+		os.Exit(1)
+	}
+
+	for _, fileName := range os.Args[1:] {
+		if err := writeDocs(os.Stdout, fileName); err != nil {
+			os.Stderr.WriteString(fmt.Sprintf("%s: %v\n", fileName, err))
+			os.Exit(1)
+		}
+	}
+}
+
+// Write the documentation of all top level functions and interfaces in
+// `fileName` to `out`
+func writeDocs(out io.Writer, fileName string) error {
+	file, err := os.Open(fileName)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	tokens, err := parser.TokenizeReader(file)
+	if err != nil {
+		return err
+	}
+	nodes, err := parser.Parse(tokens)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(out, "# %s\n", fileName)
+	for _, node := range nodes {
+		switch decl := node.(type) {
+		case parser.FunctionDeclNode:
+			args := decl.ArgumentNames
+			if decl.Rest != "" {
+				args = append(args, "..."+decl.Rest)
+			}
+			fmt.Fprintf(out, "\n## `%s(%s)`\n", decl.FuncName, strings.Join(args, ", "))
+			if decl.Doc != "" {
+				fmt.Fprintf(out, "\n%s\n", decl.Doc)
+			}
+		case parser.InterfaceDeclNode:
+			fmt.Fprintf(out, "\n## `interface %s`\n", decl.Name)
+			if decl.Doc != "" {
+				fmt.Fprintf(out, "\n%s\n", decl.Doc)
+			}
+			if len(decl.Methods) != 0 {
+				fmt.Fprintln(out)
+			}
+			for _, method := range decl.Methods {
+				fmt.Fprintf(out, "- `%s(%s)`\n", method.Name, strings.Join(method.ArgumentNames, ", "))
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,225 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jomy10/nootlang/corelib"
+	"github.com/jomy10/nootlang/interpreter"
+	"github.com/jomy10/nootlang/parser"
+	"github.com/jomy10/nootlang/runtime"
+	"github.com/jomy10/nootlang/stdlib"
+)
+
+// A meta command, entered as `:name argument`
+type command struct {
+	name  string
+	usage string
+	help  string
+	run   func(runtime *runtime.Runtime, arg string, out io.Writer) error
+}
+
+var commands []command
+
+// Initialized in `init`, because `:help` refers to `commands` itself
+func init() {
+	commands = []command{
+		{"help", ":help", "show this list", help},
+		{"vars", ":vars", "list all variables and their values", listVars},
+		{"funcs", ":funcs", "list all functions", listFuncs},
+		{"methods", ":methods [type]", "list the methods of a type (e.g. string), or of all types", listMethods},
+		{"type", ":type <expr>", "show the type of an expression", showType},
+		{"ast", ":ast <code>", "show the nodes the parser produces for the code", showAst},
+		{"tokens", ":tokens <code>", "show the tokens the tokenizer produces for the code", showTokens},
+		{"load", ":load <file.noot>", "run a file in the current session", load},
+		{"reset", ":reset", "remove all variables and functions declared in this session", reset},
+		{"time", ":time <code>", "run the code and show how long it took", timeCode},
+	}
+}
+
+// Runs the meta command in `input` (which starts with `:`)
+func runCommand(runtime *runtime.Runtime, input string, out io.Writer) error {
+	name, arg, _ := strings.Cut(strings.TrimSpace(input[1:]), " ")
+	for _, cmd := range commands {
+		if cmd.name == name {
+			return cmd.run(runtime, strings.TrimSpace(arg), out)
+		}
+	}
+	return errors.New(fmt.Sprintf("Unknown command `:%s`, type `:help` to see all commands", name))
+}
+
+func help(_ *runtime.Runtime, _ string, out io.Writer) error {
+	for _, cmd := range commands {
+		fmt.Fprintf(out, "%-20s %s\n", cmd.usage, cmd.help)
+	}
+	return nil
+}
+
+func listVars(_runtime *runtime.Runtime, _ string, out io.Writer) error {
+	for _, scope := range _runtime.Scopes {
+		names := make([]string, 0, len(_runtime.Vars[scope]))
+		for name := range _runtime.Vars[scope] {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			// Modules come from libraries, like the functions in `:funcs`
+			if _, isModule := _runtime.Vars[scope][name].(*runtime.Module); isModule {
+				continue
+			}
+			fmt.Fprintf(out, "%s = %s\n", name, corelib.Repr(_runtime.Vars[scope][name]))
+		}
+	}
+	return nil
+}
+
+func listFuncs(runtime *runtime.Runtime, _ string, out io.Writer) error {
+	for _, scope := range runtime.Scopes {
+		names := make([]string, 0, len(runtime.Funcs[scope]))
+		for name := range runtime.Funcs[scope] {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			fmt.Fprintln(out, name)
+		}
+	}
+	return nil
+}
+
+func listMethods(runtime *runtime.Runtime, typeName string, out io.Writer) error {
+	found := false
+	for ty, methods := range runtime.Methods {
+		if typeName != "" && corelib.TypeName(ty) != typeName {
+			continue
+		}
+		found = true
+
+		names := mapKeys(methods)
+		sort.Strings(names)
+		fmt.Fprintf(out, "%s: %s\n", corelib.TypeName(ty), strings.Join(names, ", "))
+	}
+
+	if !found && typeName != "" {
+		return errors.New(fmt.Sprintf("Type `%s` has no methods", typeName))
+	}
+	return nil
+}
+
+func showType(runtime *runtime.Runtime, source string, out io.Writer) error {
+	val, _, err := eval(runtime, source)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintln(out, corelib.TypeName(reflect.TypeOf(val)))
+	return nil
+}
+
+func showAst(_ *runtime.Runtime, source string, out io.Writer) error {
+	tokens, err := parser.Tokenize(source)
+	if err != nil {
+		return err
+	}
+
+	// Code that isn't a statement is shown as an expression
+	var nodes []parser.Node
+	if node, err := parser.ParseExpression(tokens); err == nil {
+		nodes = []parser.Node{node}
+	} else if nodes, err = parser.Parse(tokens); err != nil {
+		return err
+	}
+
+	for _, node := range nodes {
+		fmt.Fprintln(out, strings.ReplaceAll(fmt.Sprintf("%#v", node), "parser.", ""))
+	}
+	return nil
+}
+
+func showTokens(_ *runtime.Runtime, source string, out io.Writer) error {
+	tokens, err := parser.Tokenize(source)
+	if err != nil {
+		return err
+	}
+	for _, token := range tokens {
+		fmt.Fprintf(out, "%-6v %-16s %q\n", token.Pos, parser.TokenTypeName(token.Type), token.Value)
+	}
+	return nil
+}
+
+func load(runtime *runtime.Runtime, fileName string, out io.Writer) error {
+	if fileName == "" {
+		return errors.New("Usage: :load <file.noot>")
+	}
+	source, err := os.ReadFile(fileName)
+	if err != nil {
+		return err
+	}
+	// A file is always run as statements, even if it happens to be a single
+	// expression
+	tokens, err := parser.Tokenize(string(source))
+	if err != nil {
+		return err
+	}
+	return execStatements(runtime, tokens)
+}
+
+// Only the declarations of the session are removed, the settings of the host
+// (e.g. `Rand`, `FS` or `WarnShadowing`) are kept
+func reset(_runtime *runtime.Runtime, _ string, out io.Writer) error {
+	empty := runtime.NewRuntime(_runtime.Stdout, _runtime.Stderr, _runtime.Stdin)
+	_runtime.Scopes = empty.Scopes
+	_runtime.Vars = empty.Vars
+	_runtime.Consts = empty.Consts
+	_runtime.Funcs = empty.Funcs
+	corelib.Register(_runtime)
+	stdlib.Register(_runtime)
+	return nil
+}
+
+func timeCode(runtime *runtime.Runtime, source string, out io.Writer) error {
+	start := time.Now()
+	val, isExpr, err := eval(runtime, source)
+	elapsed := time.Since(start)
+	if err != nil {
+		return err
+	}
+	if isExpr && val != nil {
+		fmt.Fprintf(out, "> %s\n", corelib.Repr(val))
+	}
+	fmt.Fprintf(out, "took %v\n", elapsed)
+	return nil
+}
+
+// Evaluates the source as an expression, or as statements if it isn't one.
+// Returns the value of the expression and whether the source was an expression
+func eval(runtime *runtime.Runtime, source string) (interface{}, bool, error) {
+	tokens, err := parser.Tokenize(source)
+	if err != nil {
+		return nil, false, err
+	}
+
+	if node, err := parser.ParseExpression(tokens); err == nil {
+		val, err := interpreter.ExecNode(runtime, node)
+		return val, true, err
+	}
+	return nil, false, execStatements(runtime, tokens)
+}
+
+func execStatements(runtime *runtime.Runtime, tokens []parser.Token) error {
+	nodes, err := parser.Parse(tokens)
+	if err != nil {
+		return err
+	}
+	for _, node := range nodes {
+		if _, err := interpreter.ExecNode(runtime, node); err != nil {
+			return err
+		}
+	}
+	return nil
+}
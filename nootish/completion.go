@@ -0,0 +1,112 @@
+package main
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/jomy10/nootlang/runtime"
+)
+
+// Completes the word in front of the cursor. After a `.` the methods of the
+// value before it are completed, otherwise the variables and functions of the
+// runtime
+func complete(runtime *runtime.Runtime, input string, pos int) (string, []string, string) {
+	head, tail := input[:pos], input[pos:]
+
+	start := len(head)
+	for start > 0 {
+		r, size := utf8.DecodeLastRuneInString(head[:start])
+		if !isWordRune(r) {
+			break
+		}
+		start -= size
+	}
+	prefix := head[start:]
+	head = head[:start]
+
+	var candidates []string
+	if strings.HasSuffix(head, ".") {
+		candidates = methodNames(runtime, receiverName(head[:len(head)-1]))
+	} else {
+		candidates = globalNames(runtime)
+	}
+
+	var completions []string
+	for _, candidate := range candidates {
+		if strings.HasPrefix(candidate, prefix) {
+			completions = append(completions, candidate)
+		}
+	}
+	sort.Strings(completions)
+
+	return head, completions, tail
+}
+
+// Names of all variables and functions that are visible in the current scope
+func globalNames(runtime *runtime.Runtime) []string {
+	var names []string
+	for _, scope := range runtime.Scopes {
+		for name := range runtime.Vars[scope] {
+			names = append(names, name)
+		}
+		for name := range runtime.Funcs[scope] {
+			names = append(names, name)
+		}
+	}
+	return unique(names)
+}
+
+// The methods (or members, for a module) of the variable `receiver`. If it
+// isn't a variable (e.g. a literal or a function call), the methods of all
+// types are returned
+func methodNames(_runtime *runtime.Runtime, receiver string) []string {
+	if value, err := _runtime.GetVar(receiver); err == nil {
+		if module, isModule := value.(*runtime.Module); isModule {
+			var names []string
+			for name := range module.Members {
+				names = append(names, name)
+			}
+			return names
+		}
+		return mapKeys(_runtime.Methods[reflect.TypeOf(value)])
+	}
+
+	var names []string
+	for _, methods := range _runtime.Methods {
+		names = append(names, mapKeys(methods)...)
+	}
+	return unique(names)
+}
+
+// The identifier at the end of `head`, if any
+func receiverName(head string) string {
+	start := strings.LastIndexFunc(head, func(r rune) bool { return !isWordRune(r) })
+	return head[start+1:]
+}
+
+func isWordRune(r rune) bool {
+	return r == '_' || r == '!' || r == '?' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+func mapKeys(m map[string]runtime.NativeFunction) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+func unique(names []string) []string {
+	seen := make(map[string]bool)
+	var result []string
+	for _, name := range names {
+		if !seen[name] {
+			seen[name] = true
+			result = append(result, name)
+		}
+	}
+	return result
+}
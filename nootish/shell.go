@@ -1,16 +1,22 @@
 package main
 
 import (
-	"bufio"
 	"fmt"
+	"io"
 	"os"
+	"path/filepath"
+	"strings"
 
-	"github.com/jomy10/nootlang/interpreter"
+	"github.com/jomy10/nootlang/corelib"
 	"github.com/jomy10/nootlang/parser"
 	"github.com/jomy10/nootlang/runtime"
 	"github.com/jomy10/nootlang/stdlib"
+	"github.com/peterh/liner"
 )
 
+// File in the home directory where the history is kept between sessions
+const historyFileName = ".nootish_history"
+
 // TODO: accept expressions
 func main() {
 	fmt.Println(`                               $$\     $$\           $$\       
@@ -24,35 +30,147 @@ $$ |  $$ |\$$$$$$  |\$$$$$$  | \$$$$  |$$ |$$$$$$$  |$$ |  $$ |
 		`)
 
 	// Start runtime
-	runtime := runtime.NewRuntime(os.Stdout, os.Stderr, os.Stdin)
-	stdlib.Register(&runtime)
-	scanner := bufio.NewScanner(os.Stdin)
+	runtime := newRuntime()
 
-	fmt.Println("$ The nootlang interactive shell v0.0.1")
-	for {
-		fmt.Print("$ ")
+	line := liner.NewLiner()
+	defer line.Close()
+	line.SetCtrlCAborts(true)
+	line.SetWordCompleter(func(input string, pos int) (string, []string, string) {
+		return complete(&runtime, input, pos)
+	})
 
-		scanner.Scan()
-		source := scanner.Text()
+	historyFile := historyPath()
+	loadHistory(line, historyFile)
+	defer saveHistory(line, historyFile)
 
-		tokens, err := parser.Tokenize(source)
-		if err != nil {
-			os.Stderr.WriteString(err.Error() + "\n")
+	fmt.Println("$ The nootlang interactive shell v0.0.1")
+	for {
+		source, err := readInput(line)
+		if err == io.EOF {
+			// Ctrl-D
+			fmt.Println()
+			return
+		}
+		if err == liner.ErrPromptAborted {
+			// Ctrl-C discards the current input
 			continue
 		}
-		nodes, err := parser.Parse(tokens)
 		if err != nil {
 			os.Stderr.WriteString(err.Error() + "\n")
-			continue
+			return
 		}
 
-		for _, node := range nodes {
-			val, err := interpreter.ExecNode(&runtime, node)
-			if err != nil {
+		if strings.HasPrefix(strings.TrimSpace(source), ":") {
+			if err := runCommand(&runtime, source, os.Stdout); err != nil {
 				os.Stderr.WriteString(err.Error() + "\n")
-				continue
 			}
-			fmt.Printf("> %v\n", val)
+			continue
 		}
+
+		run(&runtime, source)
+	}
+}
+
+// A runtime with the core and standard library
+func newRuntime() runtime.Runtime {
+	runtime := runtime.NewRuntime(os.Stdout, os.Stderr, os.Stdin)
+	corelib.Register(&runtime)
+	stdlib.Register(&runtime)
+	return runtime
+}
+
+// Read input until all brackets, strings and comments are closed. Lines after
+// the first one get a continuation prompt
+func readInput(line *liner.State) (string, error) {
+	source, err := line.Prompt("$ ")
+	if err != nil {
+		return "", err
+	}
+	addHistory(line, source)
+
+	// Meta commands are always a single line
+	for !strings.HasPrefix(strings.TrimSpace(source), ":") && needsMoreInput(source) {
+		next, err := line.Prompt(". ")
+		if err != nil {
+			return "", err
+		}
+		addHistory(line, next)
+		source += "\n" + next
+	}
+
+	return source, nil
+}
+
+// Whether `source` has unclosed brackets, strings or block comments
+func needsMoreInput(source string) bool {
+	tokens, err := parser.Tokenize(source)
+	if err != nil {
+		syntaxErr, isSyntaxErr := err.(*parser.Error)
+		return isSyntaxErr && strings.HasPrefix(syntaxErr.Msg, "Unterminated")
+	}
+
+	level := 0
+	for _, token := range tokens {
+		switch token.Type {
+		case parser.OpenPar, parser.OpenSquarePar, parser.OpenCurlPar:
+			level += 1
+		case parser.ClosedPar, parser.ClosedSquarePar, parser.ClosedCurlPar:
+			level -= 1
+		}
+	}
+	return level > 0
+}
+
+// Execute the source. If it is an expression, its value is printed as a noot
+// literal. Statements and expressions without a value (nil) print nothing
+func run(runtime *runtime.Runtime, source string) {
+	val, isExpr, err := eval(runtime, source)
+	if err != nil {
+		os.Stderr.WriteString(err.Error() + "\n")
+		return
+	}
+	if isExpr && val != nil {
+		fmt.Printf("> %s\n", corelib.Repr(val))
+	}
+}
+
+func historyPath() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, historyFileName)
+}
+
+func loadHistory(line *liner.State, path string) {
+	if path == "" {
+		return
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		return // no history yet
+	}
+	defer file.Close()
+	line.ReadHistory(file)
+}
+
+func saveHistory(line *liner.State, path string) {
+	if path == "" {
+		return
+	}
+	file, err := os.Create(path)
+	if err != nil {
+		os.Stderr.WriteString(fmt.Sprintf("Couldn't save history: %v\n", err))
+		return
+	}
+	defer file.Close()
+	line.WriteHistory(file)
+}
+
+// Lines are added to the history one by one, so multi-line input is restored
+// correctly from the history file
+func addHistory(line *liner.State, input string) {
+	if strings.TrimSpace(input) != "" {
+		line.AppendHistory(input)
 	}
 }
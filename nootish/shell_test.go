@@ -0,0 +1,132 @@
+package main
+
+import (
+	"bytes"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/jomy10/nootlang/corelib"
+	"github.com/jomy10/nootlang/runtime"
+)
+
+func TestNeedsMoreInput(t *testing.T) {
+	incomplete := []string{"def f(a) {", "noot!(1,", "a := [1, [2]", `a := "abc`, "a := `raw", "/* comment"}
+	complete := []string{"def f(a) { return a }", "noot!(1)", "", "a := )", "a := @", `noot!("price: {")`}
+
+	for _, source := range incomplete {
+		if !needsMoreInput(source) {
+			t.Fatalf("Expected `%s` to need more input", source)
+		}
+	}
+	for _, source := range complete {
+		if needsMoreInput(source) {
+			t.Fatalf("Expected `%s` to be complete", source)
+		}
+	}
+}
+
+func TestComplete(t *testing.T) {
+	r := runtime.NewRuntime(new(bytes.Buffer), new(bytes.Buffer), os.Stdin)
+	corelib.Register(&r)
+	r.SetVar("GLOBAL", "name", "noot")
+	r.SetVar("GLOBAL", "number", int64(1))
+
+	head, completions, tail := complete(&r, "x := n + 1", 6)
+	if head != "x := " || tail != " + 1" || !reflect.DeepEqual(completions, []string{"name", "noot", "noot!", "number"}) {
+		t.Fatalf("Got %q %v %q", head, completions, tail)
+	}
+
+	head, completions, _ = complete(&r, "name.conc", 9)
+	if head != "name." || !reflect.DeepEqual(completions, []string{"concat"}) {
+		t.Fatalf("Got %q %v", head, completions)
+	}
+
+	r = newRuntime()
+	head, completions, _ = complete(&r, "math.sq", 7)
+	if head != "math." || !reflect.DeepEqual(completions, []string{"sqrt"}) {
+		t.Fatalf("Got %q %v", head, completions)
+	}
+}
+
+func TestCommands(t *testing.T) {
+	r := newRuntime()
+	if _, _, err := eval(&r, "a := [1, 2]; def f(x) { return x }"); err != nil {
+		t.Fatal(err)
+	}
+
+	testCommand(&r, ":vars", "a = [1, 2]\n", t)
+	testCommand(&r, ":type f(1.5)", "float\n", t)
+	testCommand(&r, ":type a", "array\n", t)
+	testCommand(&r, ":tokens a := 1", "1:1    Ident            \"a\"\n1:3    Declare          \":=\"\n1:6    Integer          \"1\"\n", t)
+	testCommand(&r, ":ast 1 + a", "BinaryExpressionNode{Left:IntegerLiteralNode{Value:1}, Operator:\"+\", Right:VariableNode{Name:\"a\"}}\n", t)
+	testCommand(&r, ":reset", "", t)
+	testCommand(&r, ":vars", "", t)
+
+	if err := runCommand(&r, ":nope", new(bytes.Buffer)); err == nil {
+		t.Fatal("Expected an error for an unknown command")
+	}
+}
+
+// Resetting keeps the settings of the host and the libraries
+func TestReset(t *testing.T) {
+	r := newRuntime()
+	stderr := new(bytes.Buffer)
+	r.Stderr = stderr
+	r.WarnShadowing = true
+	random := rand.New(rand.NewSource(1))
+	r.Rand = random
+	if _, _, err := eval(&r, "a := 1"); err != nil {
+		t.Fatal(err)
+	}
+
+	testCommand(&r, ":reset", "", t)
+	if r.Stderr != stderr || !r.WarnShadowing || r.Rand != random {
+		t.Fatal("Expected `:reset` to keep the settings of the runtime")
+	}
+	val, _, err := eval(&r, `"noot".len() + math.abs(-1)`)
+	if err != nil || val != int64(5) {
+		t.Fatalf("Got %v, %v after `:reset`", val, err)
+	}
+	if _, _, err := eval(&r, "a"); err == nil {
+		t.Fatal("Expected `a` to be removed by `:reset`")
+	}
+}
+
+// A file is run as statements, even if it is a single expression
+func TestLoad(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "a.noot")
+	if err := os.WriteFile(file, []byte("noot!(1)\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	r := newRuntime()
+	stdout := new(bytes.Buffer)
+	r.Stdout = stdout
+	testCommand(&r, ":load "+file, "", t)
+	if stdout.String() != "1\n" {
+		t.Fatalf("Got stdout %q", stdout.String())
+	}
+
+	// As a statement, a line starting with `[` is a destructuring declaration
+	if err := os.WriteFile(file, []byte("[noot!(2)]\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := runCommand(&r, ":load "+file, new(bytes.Buffer)); err == nil {
+		t.Fatal("Expected an error for a file that isn't valid as statements")
+	}
+	if stdout.String() != "1\n" {
+		t.Fatalf("Got stdout %q", stdout.String())
+	}
+}
+
+func testCommand(r *runtime.Runtime, input string, expected string, t *testing.T) {
+	out := new(bytes.Buffer)
+	if err := runCommand(r, input, out); err != nil {
+		t.Fatal(err)
+	}
+	if out.String() != expected {
+		t.Fatalf("`%s` printed %q, but expected %q", input, out.String(), expected)
+	}
+}
@@ -1,46 +1,46 @@
 package parser
 
-import "errors"
+import (
+	"errors"
+	"fmt"
+)
 
 func parseBinaryExpression(tokenIter Iterator[Token]) (Node, error) {
 	// Of the form Node Operator Node Operator Node (...)
 	// where Operator is of type *Token
 	expression := []interface{}{}
-	_ = expression
-
-	// parentheses level
-	parLevel := 0
-
-	lhs := []*Token{}
-	nextToken, hasNext := tokenIter.next()
-	for hasNext {
-		switch {
-		case nextToken.Type == OpenPar:
-			parLevel += 1
-		case nextToken.Type == ClosedPar:
-			parLevel -= 1
-		case isBinaryOperator(nextToken):
-			if parLevel == 0 {
-				lhsIter := newArrayOfPointerIterator(lhs)
-				exprNode, err := parseExpression(&lhsIter)
-				if err != nil {
-					return nil, err
-				}
-				expression = append(expression, exprNode, nextToken)
-				lhs = lhs[:0]
-			} else {
-				lhs = append(lhs, nextToken)
-			}
 
-		default:
-			lhs = append(lhs, nextToken)
+	// level of (), [] and {} brackets
+	level := 0
+
+	operand := []*Token{}
+	for nextToken, hasNext := tokenIter.next(); hasNext; nextToken, hasNext = tokenIter.next() {
+		switch nextToken.Type {
+		case OpenPar, OpenSquarePar, OpenCurlPar:
+			level += 1
+		case ClosedPar, ClosedSquarePar, ClosedCurlPar:
+			level -= 1
 		}
 
-		nextToken, hasNext = tokenIter.next()
+		if level == 0 && isBinaryOperator(nextToken) && endsOperand(operand) {
+			operandIter := newArrayOfPointerIterator(operand)
+			exprNode, err := parseUnaryExpression(&operandIter)
+			if err != nil {
+				return nil, err
+			}
+			expression = append(expression, exprNode, nextToken)
+			operand = nil
+		} else {
+			operand = append(operand, nextToken)
+		}
 	}
 
-	lhsIter := newArrayOfPointerIterator(lhs)
-	exprNode, err := parseExpression(&lhsIter)
+	if len(operand) == 0 {
+		operator := expression[len(expression)-1].(*Token)
+		return nil, &Error{operator.Pos, fmt.Sprintf("Expected an expression after `%s`", operator.Value)}
+	}
+	operandIter := newArrayOfPointerIterator(operand)
+	exprNode, err := parseUnaryExpression(&operandIter)
 	if err != nil {
 		return nil, err
 	}
@@ -49,6 +49,39 @@ func parseBinaryExpression(tokenIter Iterator[Token]) (Node, error) {
 	return __parseBinaryExpression(expression)
 }
 
+// Whether the remaining tokens contain a binary operator outside of brackets
+func hasBinaryOperator(tokenIter Iterator[Token]) bool {
+	level := 0
+	var operand []*Token
+	for i := 1; ; i++ {
+		nextToken, hasNext := tokenIter.peekN(i)
+		if !hasNext {
+			return false
+		}
+
+		switch nextToken.Type {
+		case OpenPar, OpenSquarePar, OpenCurlPar:
+			level += 1
+		case ClosedPar, ClosedSquarePar, ClosedCurlPar:
+			level -= 1
+		}
+		if level == 0 && isBinaryOperator(nextToken) && endsOperand(operand) {
+			return true
+		}
+		operand = append(operand, nextToken)
+	}
+}
+
+// An operator after `operand` is a binary operator. Otherwise it is a prefix
+// operator (e.g. the `-` in `2 * -x`)
+func endsOperand(operand []*Token) bool {
+	if len(operand) == 0 {
+		return false
+	}
+	last := operand[len(operand)-1]
+	return !isBinaryOperator(last) && last.Type != Not
+}
+
 func __parseBinaryExpression(expr []interface{}) (Node, error) {
 	if len(expr) == 1 {
 		switch expr[0].(type) {
@@ -67,46 +100,46 @@ func __parseBinaryExpression(expr []interface{}) (Node, error) {
 	precedences := [][]TT{
 		{Or},
 		{And},
-		{DEqual, DNEqual, LT, GT, LTE, GTE},
+		{DEqual, DNEqual, LT, GT, LTE, GTE, Is},
 		{Plus, Minus}, // Also | and ^
 		{Star, Slash}, // Also %, <<, >>, & and &^
+		{As},
 	}
-	precedenceLevelIdx := 0
-
-	exprIdx := 0
+	// Operators are left associative, so the expression is split at the last
+	// operator with the lowest precedence
+	for _, precedence := range precedences {
+		for exprIdx := len(expr) - 1; exprIdx >= 0; exprIdx-- {
+			token, isToken := expr[exprIdx].(*Token)
+			if !isToken || !containsType(precedence, token.Type) {
+				continue
+			}
 
-	for precedenceLevelIdx != len(precedences) {
-		switch expr[exprIdx].(type) {
-		case *Token:
-			token := expr[exprIdx].(*Token)
-			for precedenceIdx := 0; precedenceIdx < len(precedences[precedenceLevelIdx]); precedenceIdx++ {
-				if token.Type == precedences[precedenceLevelIdx][precedenceIdx] {
-					lhs, err := __parseBinaryExpression(expr[:exprIdx])
-					if err != nil {
-						return nil, err
-					}
-					rhs, err := __parseBinaryExpression(expr[exprIdx+1:])
-					if err != nil {
-						return nil, err
-					}
-					return BinaryExpressionNode{
-						lhs,
-						Operator(token.Value),
-						rhs,
-					}, nil
-				}
+			lhs, err := __parseBinaryExpression(expr[:exprIdx])
+			if err != nil {
+				return nil, err
 			}
+			rhs, err := __parseBinaryExpression(expr[exprIdx+1:])
+			if err != nil {
+				return nil, err
+			}
+			return BinaryExpressionNode{
+				lhs,
+				Operator(token.Value),
+				rhs,
+			}, nil
 		}
+	}
 
-		exprIdx += 1
+	return nil, errors.New("Parser bug?")
+}
 
-		if exprIdx == len(expr) {
-			exprIdx = 0
-			precedenceLevelIdx += 1
+func containsType(types []TT, tokenType TT) bool {
+	for _, t := range types {
+		if t == tokenType {
+			return true
 		}
 	}
-
-	return nil, errors.New("Parser bug?")
+	return false
 }
 
 func isBinaryOperator(token *Token) bool {
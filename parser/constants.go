@@ -0,0 +1,111 @@
+package parser
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Finds assignments to constants (`const NAME = expr`) while parsing. A
+// constant can't be assigned with `=`, `+=` and co. or through an index. The
+// interpreter checks this as well, for assignments that can't be seen here
+// (e.g. in a function declared before the constant)
+type constChecker struct {
+	// The names declared in every scope that is being checked, and whether
+	// they are constant. The first scope is the global one
+	scopes []map[string]bool
+}
+
+// Checks a statement, and remembers the names it declares. Returns the name of
+// the constant that was assigned, with the error
+func (checker *constChecker) check(node Node) (string, error) {
+	if len(checker.scopes) == 0 {
+		checker.scopes = []map[string]bool{{}}
+	}
+
+	switch node.(type) {
+	case ConstDeclNode:
+		checker.declare(node.(ConstDeclNode).Name, true)
+	case VarDeclNode:
+		checker.declare(node.(VarDeclNode).VarName, false)
+	case DestructuringDeclNode:
+		for _, name := range node.(DestructuringDeclNode).VarNames {
+			checker.declare(name, false)
+		}
+	case InterfaceDeclNode:
+		checker.declare(node.(InterfaceDeclNode).Name, false)
+	case VarAssignNode:
+		name := node.(VarAssignNode).VarName
+		if checker.isConst(name) {
+			return name, errors.New(fmt.Sprintf("Cannot assign to constant `%s`", name))
+		}
+	case ArrayIndexAssignmentNode:
+		name := node.(ArrayIndexAssignmentNode).Array.Name
+		if checker.isConst(name) {
+			return name, errors.New(fmt.Sprintf("Cannot assign to an element of constant `%s`", name))
+		}
+	case FunctionDeclNode:
+		funcDecl := node.(FunctionDeclNode)
+		params := append([]string{funcDecl.Rest}, funcDecl.ArgumentNames...)
+		return checker.checkBlock(funcDecl.Body, params...)
+	case IfNode:
+		ifNode := node.(IfNode)
+		if name, err := checker.checkBlock(ifNode.Body); err != nil {
+			return name, err
+		}
+		if ifNode.NextElseBlock != nil {
+			return checker.check(ifNode.NextElseBlock)
+		}
+	case ElseNode:
+		return checker.checkBlock(node.(ElseNode).Body)
+	case WhileNode:
+		return checker.checkBlock(node.(WhileNode).Body)
+	}
+	return "", nil
+}
+
+// Checks the statements of a block in a new scope, in which `names` are
+// declared
+func (checker *constChecker) checkBlock(body []Node, names ...string) (string, error) {
+	checker.scopes = append(checker.scopes, map[string]bool{})
+	defer func() { checker.scopes = checker.scopes[:len(checker.scopes)-1] }()
+
+	for _, name := range names {
+		checker.declare(name, false)
+	}
+	for _, node := range body {
+		if name, err := checker.check(node); err != nil {
+			return name, err
+		}
+	}
+	return "", nil
+}
+
+func (checker *constChecker) declare(name string, isConst bool) {
+	if name == "" || name == "_" {
+		return
+	}
+	checker.scopes[len(checker.scopes)-1][name] = isConst
+}
+
+// Whether the closest declaration of `name` is a constant
+func (checker *constChecker) isConst(name string) bool {
+	for i := len(checker.scopes) - 1; i >= 0; i-- {
+		if isConst, declared := checker.scopes[i][name]; declared {
+			return isConst
+		}
+	}
+	return false
+}
+
+// Where `name` is assigned in the tokens of a statement: the first `name =`
+// (or `+=` and co.), else the first `name[`, else the start of the statement
+func assignmentPos(tokens []Token, name string) Position {
+	for _, targets := range [][]TT{{Equal, PlusEqual, MinEqual, StarEqual, SlashEqual}, {OpenSquarePar}} {
+		for i := 0; i+1 < len(tokens); i++ {
+			if tokens[i].Type == Ident && tokens[i].Value == name && containsType(targets, tokens[i+1].Type) {
+				return tokens[i].Pos
+			}
+		}
+	}
+	return tokens[0].Pos
+}
@@ -0,0 +1,40 @@
+package parser
+
+import (
+	"fmt"
+	"strings"
+)
+
+// A syntax error at a position in the source
+type Error struct {
+	Pos Position
+	Msg string
+}
+
+func (err *Error) Error() string {
+	return fmt.Sprintf("%v: %s", err.Pos, err.Msg)
+}
+
+// All syntax errors found by `Parse`, in the order they appear in the source
+type ErrorList []*Error
+
+func (list ErrorList) Error() string {
+	messages := make([]string, len(list))
+	for i, err := range list {
+		messages[i] = err.Error()
+	}
+	return strings.Join(messages, "\n")
+}
+
+// Add an error to the list. Errors that don't know where they occurred are
+// reported at `pos`
+func (list *ErrorList) add(err error, pos Position) {
+	switch err.(type) {
+	case ErrorList:
+		*list = append(*list, err.(ErrorList)...)
+	case *Error:
+		*list = append(*list, err.(*Error))
+	default:
+		*list = append(*list, &Error{pos, err.Error()})
+	}
+}
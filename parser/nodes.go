@@ -15,6 +15,8 @@ const (
 	Op_GTE                 = ">="
 	Op_Or                  = "||"
 	Op_And                 = "&&"
+	Op_Is                  = "is"
+	Op_As                  = "as"
 )
 
 const (
@@ -57,6 +59,12 @@ type VarDeclNode struct {
 	Rhs     Node
 }
 
+// const Name = Rhs, a variable that can't be assigned
+type ConstDeclNode struct {
+	Name string
+	Rhs  Node
+}
+
 // VarName = Rhs
 type VarAssignNode struct {
 	VarName string
@@ -64,6 +72,14 @@ type VarAssignNode struct {
 	Rhs     Node
 }
 
+// Names := Rhs, written as `a, b := rhs` or `[a, b] := rhs`. The right hand
+// side is a tuple or an array with an element for every name. `_` skips an
+// element
+type DestructuringDeclNode struct {
+	VarNames []string
+	Rhs      Node
+}
+
 type ArrayIndexAssignmentNode struct {
 	Array VariableNode
 	Index Node
@@ -81,6 +97,11 @@ type StringLiteralNode struct {
 	String string
 }
 
+// "text {expr} text", the parts are string literals and expressions
+type InterpolatedStringNode struct {
+	Parts []Node
+}
+
 type FloatLiteralNode struct {
 	Value float64
 }
@@ -94,6 +115,11 @@ type ArrayLiteralNode struct {
 	Values []Node
 }
 
+// ((expr,)*), also the values of `return a, b` and `a, b := c, d`
+type TupleLiteralNode struct {
+	Values []Node
+}
+
 // !(expr)
 type BinaryNotNode struct {
 	Expr Node
@@ -105,15 +131,55 @@ type FunctionCallExprNode struct {
 	Arguments []Node
 }
 
+// (identifier) = (expr) in the arguments of a call, e.g. `noot!(a, b, sep = ", ")`
+type NamedArgumentNode struct {
+	Name  string
+	Value Node
+}
+
+// (expr)(args...), calls the function the expression evaluates to, e.g.
+// `handlers[0](msg)` or `get_handler()()`
+type CallExprNode struct {
+	Callee    Node
+	Arguments []Node
+}
+
 type MethodCallExprNode struct {
 	CalledOn     Node
 	FunctionCall FunctionCallExprNode
 }
 
+// (expr).(identifier), e.g. `math.pi`
+type FieldAccessNode struct {
+	Of   Node
+	Name string
+}
+
 type FunctionDeclNode struct {
 	FuncName      string
 	ArgumentNames []string
-	Body          []Node
+	// The default value of every argument, nil for the ones without a default.
+	// nil if no argument has one
+	Defaults []Node
+	// The name of the `...rest` argument, "" if there is none
+	Rest string
+	Body []Node
+	// The `///` comments above the declaration
+	Doc string
+}
+
+// interface (identifier) { (identifier)(args...) ... }
+type InterfaceDeclNode struct {
+	Name    string
+	Methods []InterfaceMethod
+	// The `///` comments above the declaration
+	Doc string
+}
+
+// A method of an interface, e.g. `scale(factor)`
+type InterfaceMethod struct {
+	Name          string
+	ArgumentNames []string
 }
 
 // (ident)[(int)]
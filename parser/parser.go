@@ -13,11 +13,28 @@ func (e Eos) Error() string {
 	return "End of statement"
 }
 
-// Parse tokens into nodes
+// Parse tokens into nodes.
+//
+// The parser does not stop at the first syntax error. A statement that fails to
+// parse is reported and parsing continues at the next statement. If there
+// were errors, an `ErrorList` is returned together with the nodes that could
+// be parsed.
 func Parse(tokens []Token) ([]Node, error) {
+	return parseStatements(tokens, &constChecker{})
+}
+
+// Parses the statements in `tokens`. When `consts` isn't nil, the statements
+// are checked for assignments to constants. Blocks are parsed without a
+// checker, the statement they are in is checked as a whole
+func parseStatements(tokens []Token, consts *constChecker) ([]Node, error) {
+	tokens = joinContinuedLines(withoutComments(tokens))
+
 	var currentStatement []Token
 
 	nodes := []Node{}
+	var errs ErrorList
+	// Lines of `///` comments waiting for the declaration they document
+	var docLines []string
 
 	// Read until end of statement and parse (or end of input)
 	blockLevel := 0 // level of curly brackets
@@ -35,17 +52,44 @@ func Parse(tokens []Token) ([]Node, error) {
 			} else if tokens[i].Type == ClosedSquarePar {
 				arrayLevel -= 1
 			}
+
+			// A stray closing bracket makes the current statement invalid, but
+			// shouldn't swallow the rest of the source
+			if blockLevel < 0 {
+				blockLevel = 0
+			}
+			if arrayLevel < 0 {
+				arrayLevel = 0
+			}
 		}
 
 		if i == len(tokens) || (tokens[i].Type == EOS && blockLevel == 0 && arrayLevel == 0) {
 			currentStatement = tokens[start:i]
 			iter := newArrayIterator(currentStatement)
 			stmtNode, err := parseStatement(&iter)
-			if err != nil {
-				if err.Error() != "Empty statement" {
-					return nil, err
+			if err != nil && err.Error() != "Empty statement" {
+				errs.add(err, currentStatement[0].Pos)
+			}
+
+			if consts != nil && stmtNode != nil {
+				if name, err := consts.check(stmtNode); err != nil {
+					errs.add(err, assignmentPos(currentStatement, name))
+				}
+			}
+
+			// Statements with errors can still return a (partial) node
+			if docComment, isDocComment := stmtNode.(docCommentNode); isDocComment {
+				docLines = append(docLines, docComment.Text)
+			} else if stmtNode != nil {
+				switch decl := stmtNode.(type) {
+				case FunctionDeclNode:
+					decl.Doc = strings.Join(docLines, "\n")
+					stmtNode = decl
+				case InterfaceDeclNode:
+					decl.Doc = strings.Join(docLines, "\n")
+					stmtNode = decl
 				}
-			} else if stmtNode != nil { // nil check to exclude comments
+				docLines = nil
 				nodes = append(nodes, stmtNode)
 			}
 			start = i + 1
@@ -58,9 +102,148 @@ func Parse(tokens []Token) ([]Node, error) {
 		i += 1
 	}
 
+	if len(errs) != 0 {
+		return nodes, errs
+	}
 	return nodes, nil
 }
 
+// Parse tokens that form a single expression (e.g. `a + 1` or `f(x)`), which
+// can't be parsed as statements
+func ParseExpression(tokens []Token) (Node, error) {
+	tokens = joinContinuedLines(withoutComments(tokens))
+	for len(tokens) != 0 && tokens[len(tokens)-1].Type == EOS {
+		tokens = tokens[:len(tokens)-1]
+	}
+
+	iter := newArrayIterator(tokens)
+	node, err := parseExpression(&iter)
+	if err != nil {
+		return nil, err
+	}
+	if next, hasNext := iter.peek(); hasNext {
+		return nil, &Error{next.Pos, fmt.Sprintf("Unexpected `%s` after expression", next.Value)}
+	}
+	return node, nil
+}
+
+// Removes comments from the tokens. Doc comments are kept if they are on their
+// own line, so they can be attached to the declaration below them
+func withoutComments(tokens []Token) []Token {
+	filtered := make([]Token, 0, len(tokens))
+	for i, token := range tokens {
+		switch {
+		case token.Type == Comment:
+			continue
+		case token.Type == DocComment && i != 0 && tokens[i-1].Type != EOS:
+			continue
+		}
+		filtered = append(filtered, token)
+	}
+	return filtered
+}
+
+// Removes the newlines that don't end a statement, so that statements can be
+// continued on the next line. A newline does not end a statement when it is
+//   - inside of parentheses or square brackets (but not inside a `{}` block in them)
+//   - after an operator, `,` or `.` (e.g. `a +` or `f(a,`)
+//   - before `else`, `elsif` or `.` (e.g. `}` followed by `else` on the next line)
+func joinContinuedLines(tokens []Token) []Token {
+	joined := make([]Token, 0, len(tokens))
+	// The opening brackets we are currently in
+	var brackets []TT
+
+	for i, token := range tokens {
+		switch token.Type {
+		case OpenPar, OpenSquarePar, OpenCurlPar:
+			brackets = append(brackets, token.Type)
+		case ClosedPar, ClosedSquarePar, ClosedCurlPar:
+			// Stray closing brackets are reported by the parser
+			if len(brackets) != 0 && brackets[len(brackets)-1] == openingBracket(token.Type) {
+				brackets = brackets[:len(brackets)-1]
+			}
+		}
+
+		if token.Type == EOS && token.Value == "\n" {
+			insideBrackets := len(brackets) != 0 && brackets[len(brackets)-1] != OpenCurlPar
+			if insideBrackets || continuesAfter(joined) || continuesBefore(tokens[i+1:]) {
+				continue
+			}
+		}
+
+		joined = append(joined, token)
+	}
+
+	return joined
+}
+
+func openingBracket(closing TT) TT {
+	switch closing {
+	case ClosedPar:
+		return OpenPar
+	case ClosedSquarePar:
+		return OpenSquarePar
+	default:
+		return OpenCurlPar
+	}
+}
+
+// How a bracket is written in the source
+func bracketValue(bracket TT) string {
+	switch bracket {
+	case OpenPar:
+		return "("
+	case ClosedPar:
+		return ")"
+	case OpenSquarePar:
+		return "["
+	case ClosedSquarePar:
+		return "]"
+	case OpenCurlPar:
+		return "{"
+	default:
+		return "}"
+	}
+}
+
+// Whether the last token of `tokens` expects the statement to go on
+func continuesAfter(tokens []Token) bool {
+	if len(tokens) == 0 {
+		return false
+	}
+	last := &tokens[len(tokens)-1]
+	switch last.Type {
+	case Declare, Equal, PlusEqual, MinEqual, StarEqual, SlashEqual, Not, Comma, Dot:
+		return true
+	default:
+		return isBinaryOperator(last)
+	}
+}
+
+// Whether the first token after the newlines at the start of `tokens` continues
+// the statement before them
+func continuesBefore(tokens []Token) bool {
+	for _, token := range tokens {
+		if token.Type == EOS && token.Value == "\n" {
+			continue
+		}
+		return token.Type == Else || token.Type == Elsif || token.Type == Dot
+	}
+	return false
+}
+
+// A line of a doc comment, only used while parsing. `Parse` attaches these to
+// the declaration that follows
+type docCommentNode struct {
+	Text string
+}
+
+// The text of a doc comment without the slashes
+func docCommentText(comment string) string {
+	text := strings.TrimPrefix(comment, "///")
+	return strings.TrimPrefix(text, " ")
+}
+
 func parseStatement(tokenIter Iterator[Token]) (Node, error) {
 	firstToken, hasFirst := tokenIter.next()
 	if !hasFirst {
@@ -71,11 +254,15 @@ func parseStatement(tokenIter Iterator[Token]) (Node, error) {
 	case Ident:
 		secondToken, hasSecond := tokenIter.peek()
 		if !hasSecond {
-			return nil, errors.New("Invalid statement: lonely identifier")
+			return nil, &Error{firstToken.Pos, "Invalid statement: lonely identifier"}
 		}
 		switch secondToken.Type {
-		case OpenPar:
-			return parseFunctionCall(firstToken.Value, tokenIter)
+		case OpenPar, Dot:
+			tokenIter.reverse(1)
+			return parseCallStatement(tokenIter)
+		case Comma:
+			tokenIter.reverse(1)
+			return parseDestructuring(tokenIter)
 		case Declare, Equal, PlusEqual, MinEqual, StarEqual, SlashEqual:
 			_, _ = tokenIter.next() // consume :=/=
 			exprNode, err := parseExpression(tokenIter)
@@ -89,6 +276,11 @@ func parseStatement(tokenIter Iterator[Token]) (Node, error) {
 				return VarAssignNode{firstToken.Value, Operator(secondToken.Value), exprNode}, nil
 			}
 		case OpenSquarePar:
+			// `a[i](args)` calls the element
+			if !hasAssignment(tokenIter) {
+				tokenIter.reverse(1)
+				return parseCallStatement(tokenIter)
+			}
 			idxNode, err := parseArrayIndex(tokenIter)
 			if err != nil {
 				return nil, err
@@ -96,7 +288,7 @@ func parseStatement(tokenIter Iterator[Token]) (Node, error) {
 
 			nextToken, hasNext := tokenIter.next()
 			if !hasNext {
-				return nil, errors.New("Cannot use array index expression as statement")
+				return nil, &Error{firstToken.Pos, "Cannot use array index expression as statement"}
 			}
 
 			if nextToken.Type == Equal {
@@ -105,37 +297,33 @@ func parseStatement(tokenIter Iterator[Token]) (Node, error) {
 					return nil, err
 				}
 				return ArrayIndexAssignmentNode{
-					VariableNode{"a"},
+					VariableNode{firstToken.Value},
 					idxNode,
 					rhs,
 				}, nil
 			} else {
-				return nil, errors.New(fmt.Sprintf("Unexpected token %s", nextToken.Value))
+				return nil, &Error{nextToken.Pos, fmt.Sprintf("Unexpected `%s`", nextToken.Value)}
 			}
-		case Dot:
-			tokenIter.consume(1) // consume dot
-			return parseMethodCall(VariableNode{firstToken.Value}, tokenIter)
 		default:
-			return nil, errors.New(fmt.Sprintf("%#v is invalid at current position", secondToken))
+			return nil, &Error{secondToken.Pos, fmt.Sprintf("Unexpected `%s`", secondToken.Value)}
 		}
 	case String, Integer, Float, Bool:
 		// Literals follew by a dot are valid in statements
-		secondToken, hasSecond := tokenIter.next()
+		secondToken, hasSecond := tokenIter.peek()
 		if !hasSecond {
-			return nil, errors.New("Literals cannot be used as statements")
+			return nil, &Error{firstToken.Pos, "Literals cannot be used as statements"}
 		}
 		if secondToken.Type == Dot {
-			firstTokenIter := newArrayOfPointerIterator([]*Token{firstToken})
-			lhsexpr, err := parseExpression(&firstTokenIter)
-			if err != nil {
-				return nil, err
-			}
-			return parseMethodCall(lhsexpr, tokenIter)
+			tokenIter.reverse(1)
+			return parseCallStatement(tokenIter)
 		} else {
-			return nil, errors.New(fmt.Sprintf("Invalid token %v", secondToken))
+			return nil, &Error{secondToken.Pos, fmt.Sprintf("Unexpected `%s` after a literal", secondToken.Value)}
 		}
+	case OpenSquarePar:
+		tokenIter.reverse(1)
+		return parseDestructuring(tokenIter)
 	case Return:
-		expr, err := parseExpression(tokenIter)
+		expr, err := parseExpressionList(tokenIter)
 		if err != nil {
 			return nil, err
 		}
@@ -147,193 +335,361 @@ func parseStatement(tokenIter Iterator[Token]) (Node, error) {
 		return parseIf(tokenIter)
 	case While:
 		return parseWhile(tokenIter)
+	case Interface:
+		return parseInterfaceDecl(tokenIter)
+	case Const:
+		return parseConstDecl(tokenIter)
 	case Comment:
 		return nil, nil // Currently ignored
+	case DocComment:
+		if nextToken, hasNext := tokenIter.peek(); hasNext {
+			return nil, &Error{nextToken.Pos, "Expected a new line after doc comment"}
+		}
+		return docCommentNode{docCommentText(firstToken.Value)}, nil
 	default:
-		return nil, errors.New(fmt.Sprintf("Node %#v is invalid at current position", firstToken))
+		return nil, &Error{firstToken.Pos, fmt.Sprintf("Unexpected `%s` at the start of a statement", firstToken.Value)}
 	}
 }
 
-func parseExpression(tokenIter Iterator[Token]) (Node, error) {
-	firstToken, hasFirst := tokenIter.peek()
-	if !hasFirst {
-		return nil, errors.New("expected expression")
+// `const NAME = expr`, tokenIter starts at the name
+func parseConstDecl(tokenIter Iterator[Token]) (Node, error) {
+	nameToken, hasName := tokenIter.next()
+	if !hasName || nameToken.Type != Ident {
+		return nil, &Error{tokenIter.prev().Pos, "Expected a name after `const`"}
+	}
+	equalToken, hasEqual := tokenIter.next()
+	if !hasEqual || equalToken.Type != Equal {
+		return nil, &Error{tokenIter.prev().Pos, fmt.Sprintf("Expected `=` after `const %s`", nameToken.Value)}
+	}
+	rhs, err := parseExpression(tokenIter)
+	if err != nil {
+		return nil, err
 	}
+	return ConstDeclNode{nameToken.Value, rhs}, nil
+}
 
-	switch firstToken.Type {
-	case OpenPar:
-		return parseBinaryExpression(tokenIter)
-	case Bool:
-		secondToken, hasSecond := tokenIter.peekN(2)
-		// handle lonesome boolean literal
-		if !hasSecond {
-			tokenIter.consume(1) // consume boolean
-			boolean, err := strconv.ParseBool(firstToken.Value)
-			if err != nil {
-				return nil, err
-			}
-			return BoolLiteralNode{boolean}, nil
-		} else {
-			if secondToken.Type == Dot {
-				tokenIter.consume(2) // (bool).
-				boolean, err := strconv.ParseBool(firstToken.Value)
-				if err != nil {
-					return nil, err
-				}
-				return parseMethodCall(BoolLiteralNode{boolean}, tokenIter)
-			} else {
-				return parseBinaryExpression(tokenIter)
-			}
+// Whether the remaining tokens contain a `=` outside of brackets
+func hasAssignment(tokenIter Iterator[Token]) bool {
+	level := 0
+	for i := 1; ; i++ {
+		nextToken, hasNext := tokenIter.peekN(i)
+		if !hasNext {
+			return false
 		}
-	case Integer:
-		secondToken, hasSecond := tokenIter.peekN(2)
-		// Handle lonesome integer literal
-		if !hasSecond {
-			tokenIter.consume(1) // consume integer
-			integer, err := strconv.ParseInt(firstToken.Value, 10, 64)
-			if err != nil {
-				return nil, err
-			}
-			return IntegerLiteralNode{integer}, nil
-		} else {
-			if secondToken.Type == Dot {
-				tokenIter.consume(2) // consume (int).
-				integer, err := strconv.ParseInt(firstToken.Value, 10, 64)
-				if err != nil {
-					return nil, err
-				}
-				return parseMethodCall(IntegerLiteralNode{integer}, tokenIter)
-			} else {
-				return parseBinaryExpression(tokenIter)
+		switch nextToken.Type {
+		case OpenPar, OpenSquarePar, OpenCurlPar:
+			level += 1
+		case ClosedPar, ClosedSquarePar, ClosedCurlPar:
+			level -= 1
+		case Equal:
+			if level == 0 {
+				return true
 			}
 		}
-	case Float:
-		secondToken, hasSecond := tokenIter.peekN(2)
-		// Handle lonesome float literal
-		if !hasSecond {
-			tokenIter.consume(1) // consume float
-			float, err := strconv.ParseFloat(firstToken.Value, 64)
-			if err != nil {
-				return nil, err
-			}
-			return FloatLiteralNode{float}, nil
-		} else {
-			if secondToken.Type == Dot {
-				tokenIter.consume(2) // consume (int).
-				float, err := strconv.ParseFloat(firstToken.Value, 64)
-				if err != nil {
-					return nil, err
-				}
-				return parseMethodCall(FloatLiteralNode{float}, tokenIter)
-			} else {
-				return parseBinaryExpression(tokenIter)
-			}
+	}
+}
+
+// A function or method call used as a statement, e.g. `f(x)` or `a.push(1)`
+func parseCallStatement(tokenIter Iterator[Token]) (Node, error) {
+	node, err := parseExpression(tokenIter)
+	if err != nil {
+		return nil, err
+	}
+	switch node.(type) {
+	case FunctionCallExprNode, MethodCallExprNode, CallExprNode:
+		return node, nil
+	default:
+		return nil, errors.New("Only function and method calls can be used as statements")
+	}
+}
+
+// `a, b := rhs` or `[a, b] := rhs`
+func parseDestructuring(tokenIter Iterator[Token]) (Node, error) {
+	var names []*Token
+	firstToken, _ := tokenIter.peek()
+	if firstToken.Type == OpenSquarePar {
+		tokenIter.consume(1)
+		bracketed, err := collectBracketed(tokenIter, ClosedSquarePar)
+		if err != nil {
+			return nil, err
 		}
-	case Ident:
-		secondToken, hasSecond := tokenIter.peekN(2)
-		// Handle lonesome ident
-		if !hasSecond {
-			tokenIter.consume(1) // consume ident
-			return VariableNode{firstToken.Value}, nil
+		names = bracketed
+	} else {
+		for nextToken, hasNext := tokenIter.peek(); hasNext && nextToken.Type != Declare; nextToken, hasNext = tokenIter.peek() {
+			names = append(names, nextToken)
+			tokenIter.consume(1)
 		}
+	}
 
-		switch secondToken.Type {
-		case OpenPar:
-			// function call
-			tokenIter.consume(1) // consume ident
-			return parseFunctionCall(firstToken.Value, tokenIter)
-		case OpenSquarePar:
-			tokenIter.consume(1) // consume ident
-			innerIndexExpression, err := parseArrayIndex(tokenIter)
-			if err != nil {
-				return nil, err
+	declare, hasDeclare := tokenIter.next()
+	if !hasDeclare || declare.Type != Declare {
+		return nil, &Error{tokenIter.prev().Pos, "Expected `:=` after the names to destructure into"}
+	}
+
+	parts, _ := splitOnCommas(names)
+	varNames := make([]string, len(parts))
+	for i, part := range parts {
+		if len(part) != 1 || part[0].Type != Ident {
+			pos := declare.Pos
+			if len(part) != 0 {
+				pos = part[0].Pos
 			}
-			return ArrayIndexNode{
-				VariableNode{firstToken.Value},
-				innerIndexExpression,
-			}, nil
-		case Dot:
-			tokenIter.consume(2) // consume ident and dot
-			return parseMethodCall(VariableNode{firstToken.Value}, tokenIter)
-		default:
-			return parseBinaryExpression(tokenIter)
+			return nil, &Error{pos, "Expected a comma separated list of names to destructure into"}
 		}
-	case Nil:
-		tokenIter.consume(1)
-		return NilLiteralNode{}, nil
-	case String:
-		secondToken, hasSecond := tokenIter.peekN(2)
-		if !hasSecond {
-			tokenIter.consume(1)
-			return StringLiteralNode{parseStringLiteral(firstToken.Value)}, nil
+		varNames[i] = part[0].Value
+	}
+	if len(varNames) == 0 {
+		return nil, &Error{declare.Pos, "Expected names to destructure into"}
+	}
+
+	rhs, err := parseExpressionList(tokenIter)
+	if err != nil {
+		return nil, err
+	}
+	return DestructuringDeclNode{varNames, rhs}, nil
+}
+
+// An expression, or a tuple if there are commas outside of brackets (`a, b`)
+func parseExpressionList(tokenIter Iterator[Token]) (Node, error) {
+	var tokens []*Token
+	for nextToken, hasNext := tokenIter.next(); hasNext; nextToken, hasNext = tokenIter.next() {
+		tokens = append(tokens, nextToken)
+	}
+
+	elements, isTuple := splitOnCommas(tokens)
+	if !isTuple || len(tokens) == 0 {
+		iter := newArrayOfPointerIterator(tokens)
+		return parseExpression(&iter)
+	}
+	return parseTupleLiteral(elements)
+}
+
+func parseTupleLiteral(elements [][]*Token) (Node, error) {
+	values := make([]Node, len(elements))
+	for i, element := range elements {
+		if len(element) == 0 {
+			return nil, errors.New("Expected an expression between the commas of a tuple")
 		}
+		iter := newArrayOfPointerIterator(element)
+		value, err := parseExpression(&iter)
+		if err != nil {
+			return nil, err
+		}
+		values[i] = value
+	}
+	return TupleLiteralNode{values}, nil
+}
 
-		if secondToken.Type == Plus {
-			return parseBinaryExpression(tokenIter)
-		} else {
-			if secondToken.Type == Dot {
-				tokenIter.consume(2) // consume (str).
-				str := parseStringLiteral(firstToken.Value)
-				return parseMethodCall(str, tokenIter)
-			} else {
-				return nil, errors.New(fmt.Sprintf("Did not expect token %s afte string literal\n", secondToken.Value))
-			}
+// Splits the tokens on the commas outside of brackets. A trailing comma is
+// allowed, so `a,` is a single element. `isTuple` is whether there was a comma,
+// or no tokens at all (`()`)
+func splitOnCommas(tokens []*Token) (elements [][]*Token, isTuple bool) {
+	if len(tokens) == 0 {
+		return nil, true
+	}
+
+	level := 0
+	var element []*Token
+	for _, token := range tokens {
+		switch token.Type {
+		case OpenPar, OpenSquarePar, OpenCurlPar:
+			level += 1
+		case ClosedPar, ClosedSquarePar, ClosedCurlPar:
+			level -= 1
+		}
+		if token.Type == Comma && level == 0 {
+			elements = append(elements, element)
+			element = nil
+			isTuple = true
+			continue
 		}
+		element = append(element, token)
+	}
+	if len(element) != 0 || !isTuple {
+		elements = append(elements, element)
+	}
+	return elements, isTuple
+}
+
+// Parses all remaining tokens as one expression
+func parseExpression(tokenIter Iterator[Token]) (Node, error) {
+	if !tokenIter.hasNext() {
+		return nil, errors.New("expected expression")
+	}
+	if hasBinaryOperator(tokenIter) {
+		return parseBinaryExpression(tokenIter)
+	}
+	return parseUnaryExpression(tokenIter)
+}
+
+// An expression with optional prefix operators, e.g. `!a.b` or `-f(x)[0]`
+func parseUnaryExpression(tokenIter Iterator[Token]) (Node, error) {
+	firstToken, hasFirst := tokenIter.peek()
+	if !hasFirst {
+		operator := tokenIter.prev()
+		return nil, &Error{operator.Pos, fmt.Sprintf("Expected an expression after `%s`", operator.Value)}
+	}
+	switch firstToken.Type {
 	case Not:
 		tokenIter.consume(1)
-		node, err := parseExpression(tokenIter)
+		node, err := parseUnaryExpression(tokenIter)
 		if err != nil {
 			return nil, err
 		}
 		return BinaryNotNode{node}, nil
+	case Minus:
+		tokenIter.consume(1)
+		node, err := parseUnaryExpression(tokenIter)
+		if err != nil {
+			return nil, err
+		}
+		return BinaryExpressionNode{IntegerLiteralNode{0}, Op_Min, node}, nil
+	}
+
+	node, err := parsePrimaryExpression(tokenIter)
+	if err != nil {
+		return nil, err
+	}
+	return parsePostfixExpression(node, tokenIter)
+}
+
+// A literal, variable, function call or an expression between parentheses
+func parsePrimaryExpression(tokenIter Iterator[Token]) (Node, error) {
+	firstToken, hasFirst := tokenIter.next()
+	if !hasFirst {
+		return nil, errors.New("expected expression")
+	}
+
+	switch firstToken.Type {
+	case OpenPar:
+		inner, err := collectBracketed(tokenIter, ClosedPar)
+		if err != nil {
+			return nil, err
+		}
+		elements, isTuple := splitOnCommas(inner)
+		if !isTuple {
+			innerIter := newArrayOfPointerIterator(inner)
+			return parseExpression(&innerIter)
+		}
+		return parseTupleLiteral(elements)
+	case Integer:
+		integer, err := strconv.ParseInt(firstToken.Value, 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		return IntegerLiteralNode{integer}, nil
+	case Float:
+		float, err := strconv.ParseFloat(firstToken.Value, 64)
+		if err != nil {
+			return nil, err
+		}
+		return FloatLiteralNode{float}, nil
+	case Bool:
+		boolean, err := strconv.ParseBool(firstToken.Value)
+		if err != nil {
+			return nil, err
+		}
+		return BoolLiteralNode{boolean}, nil
+	case Nil:
+		return NilLiteralNode{}, nil
+	case String:
+		return parseStringLiteral(firstToken)
+	case Ident:
+		if nextToken, hasNext := tokenIter.peek(); hasNext && nextToken.Type == OpenPar {
+			return parseFunctionCall(firstToken.Value, tokenIter)
+		}
+		return VariableNode{firstToken.Value}, nil
 	case OpenSquarePar: // start of array initialization
+		tokenIter.reverse(1)
 		return parseArrayLiteral(tokenIter)
 	default:
-		return nil, errors.New(fmt.Sprintf("Invalid start of expression `%v`", firstToken))
+		return nil, &Error{firstToken.Pos, fmt.Sprintf("Invalid start of expression `%s`", firstToken.Value)}
 	}
 }
 
-// tokenIter is at the method name (ident)
-func parseMethodCall(calledOn Node, tokenIter Iterator[Token]) (Node, error) {
-	funcNameToken, hasNext := tokenIter.next()
-	if !hasNext || funcNameToken.Type != Ident {
-		return nil, errors.New("Expected function name after `.`")
-	}
-	funcCallNode, err := parseFunctionCall(funcNameToken.Value, tokenIter)
-	if err != nil {
-		return nil, err
+// Parses method calls (`.name(args)`), field accesses (`.name`), indexes
+// (`[expr]`) and calls (`(args)`) following `node`
+func parsePostfixExpression(node Node, tokenIter Iterator[Token]) (Node, error) {
+	for {
+		nextToken, hasNext := tokenIter.peek()
+		if !hasNext {
+			return node, nil
+		}
+
+		switch nextToken.Type {
+		case Dot:
+			tokenIter.consume(1) // consume dot
+			nameToken, hasName := tokenIter.next()
+			if !hasName || nameToken.Type != Ident {
+				return nil, &Error{nextToken.Pos, "Expected a name after `.`"}
+			}
+			if openPar, hasOpenPar := tokenIter.peek(); hasOpenPar && openPar.Type == OpenPar {
+				funcCallNode, err := parseFunctionCall(nameToken.Value, tokenIter)
+				if err != nil {
+					return nil, err
+				}
+				node = MethodCallExprNode{node, funcCallNode}
+			} else {
+				node = FieldAccessNode{node, nameToken.Value}
+			}
+		case OpenSquarePar:
+			index, err := parseArrayIndex(tokenIter)
+			if err != nil {
+				return nil, err
+			}
+			node = ArrayIndexNode{node, index}
+		case OpenPar:
+			args, err := parseFunctionCallArguments(tokenIter)
+			if err != nil {
+				return nil, err
+			}
+			node = CallExprNode{node, args}
+		default:
+			return nil, &Error{nextToken.Pos, fmt.Sprintf("Unexpected `%s` in expression", nextToken.Value)}
+		}
 	}
-	return MethodCallExprNode{
-		calledOn,
-		funcCallNode,
-	}, nil
 }
 
-// tokenIter is at [
-func parseArrayIndex(tokenIter Iterator[Token]) (Node, error) {
-	tokenIter.consume(1) // [
-	arrayLevel := 1
-
-	var expression []*Token
+// Collects the tokens up to the bracket of type `closing` that closes the
+// bracket before tokenIter. The closing bracket is consumed, but not returned
+func collectBracketed(tokenIter Iterator[Token], closing TT) ([]*Token, error) {
+	opening := tokenIter.prev()
+	level := 0
+	var tokens []*Token
 	for {
 		nextToken, hasNext := tokenIter.next()
 		if !hasNext {
-			return nil, errors.New("Expected ] to close array index")
+			return nil, &Error{opening.Pos, fmt.Sprintf("Expected `%s` to close `%s`", bracketValue(closing), bracketValue(openingBracket(closing)))}
 		}
 
-		if nextToken.Type == ClosedSquarePar {
-			arrayLevel -= 1
-		} else if nextToken.Type == OpenSquarePar {
-			arrayLevel += 1
+		switch nextToken.Type {
+		case OpenPar, OpenSquarePar, OpenCurlPar:
+			level += 1
+		case ClosedPar, ClosedSquarePar, ClosedCurlPar:
+			if level == 0 {
+				if nextToken.Type != closing {
+					return nil, &Error{nextToken.Pos, fmt.Sprintf("Expected `%s`, but got `%s`", bracketValue(closing), nextToken.Value)}
+				}
+				return tokens, nil
+			}
+			level -= 1
 		}
+		tokens = append(tokens, nextToken)
+	}
+}
 
-		if arrayLevel == 0 {
-			exprIter := newArrayOfPointerIterator(expression)
-			return parseExpression(&exprIter)
-		} else {
-			expression = append(expression, nextToken)
-		}
+// tokenIter is at [
+func parseArrayIndex(tokenIter Iterator[Token]) (Node, error) {
+	openToken, _ := tokenIter.next() // [
+	expression, err := collectBracketed(tokenIter, ClosedSquarePar)
+	if err != nil {
+		return nil, err
+	}
+	if len(expression) == 0 {
+		return nil, &Error{openToken.Pos, "Expected an index between `[]`"}
 	}
+	exprIter := newArrayOfPointerIterator(expression)
+	return parseExpression(&exprIter)
 }
 
 // tokenIter starts at [
@@ -368,7 +724,7 @@ func parseWhile(tokenIter Iterator[Token]) (Node, error) {
 		nextToken, hasNext := tokenIter.peek()
 
 		if !hasNext {
-			return nil, errors.New("Expected opening curly bracket after while condition")
+			return nil, &Error{tokenIter.prev().Pos, "Expected opening curly bracket after while condition"}
 		}
 
 		if nextToken.Type == OpenCurlPar {
@@ -380,7 +736,7 @@ func parseWhile(tokenIter Iterator[Token]) (Node, error) {
 	}
 
 	if len(condition) == 0 {
-		return nil, errors.New("While loop has empty condition")
+		return nil, &Error{tokenIter.prev().Pos, "While loop has empty condition"}
 	}
 
 	conditionIter := newArrayOfPointerIterator(condition)
@@ -389,15 +745,14 @@ func parseWhile(tokenIter Iterator[Token]) (Node, error) {
 		return nil, err
 	}
 
+	// The loop is returned even if its body has errors, so it is part of the
+	// partial AST
 	body, err := parseBody(tokenIter)
-	if err != nil {
-		return nil, err
-	}
 
 	return WhileNode{
 		expr,
 		body,
-	}, nil
+	}, err
 }
 
 // Parse if or elsif (starting at if or elsi)
@@ -408,7 +763,7 @@ func parseIf(tokenIter Iterator[Token]) (Node, error) {
 		nextToken, hasNext := tokenIter.peek()
 		for {
 			if !hasNext {
-				return nil, errors.New("If exepcted an opening curly bracket")
+				return nil, &Error{tokenIter.prev().Pos, "If exepcted an opening curly bracket"}
 			}
 			if nextToken.Type == OpenCurlPar {
 				break
@@ -433,7 +788,10 @@ func parseIf(tokenIter Iterator[Token]) (Node, error) {
 
 	bodyExpr, err := parseBody(tokenIter)
 	if err != nil {
-		return nil, err
+		if ifToken.Type == Else {
+			return ElseNode{bodyExpr}, err
+		}
+		return IfNode{conditionExpr, nil, bodyExpr}, err
 	}
 
 	nextToken, hasNext := tokenIter.peek()
@@ -465,14 +823,14 @@ func parseFunctionCall(name string, tokenIter Iterator[Token]) (FunctionCallExpr
 	return FunctionCallExprNode{name, args}, err
 }
 
-// Parse ( args ,* )
+// Parse ( args ,* ), named arguments (`name = expr`) come last
 func parseFunctionCallArguments(tokenIter Iterator[Token]) ([]Node, error) {
 	openPar, hasOpenPar := tokenIter.next()
 	if !hasOpenPar {
 		return nil, errors.New("Expected opening parenthesis in function call")
 	}
 	if openPar.Type != OpenPar {
-		return nil, errors.New(fmt.Sprintf("Expected opening parenthesis in function call, but got %s", openPar.Value))
+		return nil, &Error{openPar.Pos, fmt.Sprintf("Expected opening parenthesis in function call, but got %s", openPar.Value)}
 	}
 
 	argList, err := collectList(tokenIter, ClosedPar)
@@ -481,7 +839,22 @@ func parseFunctionCallArguments(tokenIter Iterator[Token]) ([]Node, error) {
 	}
 
 	var args []Node
+	named := false
 	for _, arg := range argList {
+		if len(arg) >= 2 && arg[0].Type == Ident && arg[1].Type == Equal {
+			named = true
+			argIter := newArrayOfPointerIterator(arg[2:])
+			expr, err := parseExpression(&argIter)
+			if err != nil {
+				return nil, err
+			}
+			args = append(args, NamedArgumentNode{arg[0].Value, expr})
+			continue
+		}
+		if named {
+			return nil, &Error{arg[0].Pos, "Positional arguments have to come before named arguments"}
+		}
+
 		argIter := newArrayOfPointerIterator(arg)
 		expr, err := parseExpression(&argIter)
 		if err != nil {
@@ -497,50 +870,179 @@ func parseFunctionCallArguments(tokenIter Iterator[Token]) ([]Node, error) {
 func parseFunctionDecl(tokenIter Iterator[Token]) (Node, error) {
 	funcNameToken, hasNameToken := tokenIter.next()
 	if !hasNameToken {
-		return nil, errors.New("Expected function name after `def`")
+		return nil, &Error{tokenIter.prev().Pos, "Expected function name after `def`"}
 	}
 	if funcNameToken.Type != Ident {
-		return nil, errors.New(fmt.Sprintf("Expected function name after `def`, get %s", funcNameToken.Value))
+		return nil, &Error{funcNameToken.Pos, fmt.Sprintf("Expected function name after `def`, get %s", funcNameToken.Value)}
 	}
 
-	args, err := parseFunctionDeclArgs(tokenIter)
+	params, err := parseFunctionDeclArgs(tokenIter)
 	if err != nil {
 		return nil, err
 	}
 
+	// The function is returned even if its body has errors, so it is part of the
+	// partial AST
 	body, err := parseBody(tokenIter)
-	if err != nil {
-		return nil, err
-	}
 
-	return FunctionDeclNode{funcNameToken.Value, args, body}, nil
+	return FunctionDeclNode{funcNameToken.Value, params.names, params.defaults, params.rest, body, ""}, err
+}
+
+// The arguments of a function declaration
+type declParams struct {
+	names    []string
+	defaults []Node
+	rest     string
 }
 
-// Returns the arguments of a function declaration as strings.
+// Returns the arguments of a function declaration: `name`, `name = default`
+// and a last `...rest`. Arguments with a default come after the ones without.
 // tokenIter starts at the opened paranthesis
-func parseFunctionDeclArgs(tokenIter Iterator[Token]) ([]string, error) {
+func parseFunctionDeclArgs(tokenIter Iterator[Token]) (declParams, error) {
+	var params declParams
 	openedPar, hasOpenedPar := tokenIter.next()
 	if !hasOpenedPar {
-		return nil, errors.New("Expected opening bracket after function declaration")
+		return params, errors.New("Expected opening bracket after function declaration")
 	}
 	if openedPar.Type != OpenPar {
-		return nil, errors.New(fmt.Sprintf("Expected opening brakcet after function declaration, but got %s", openedPar.Value))
+		return params, &Error{openedPar.Pos, fmt.Sprintf("Expected opening brakcet after function declaration, but got %s", openedPar.Value)}
 	}
 
 	list, err := collectList(tokenIter, ClosedPar)
 	if err != nil {
-		return nil, err
+		return params, err
 	}
 
-	var argNames []string
-	for _, arg := range list {
+	hasDefaults := false
+	for i, arg := range list {
+		if params.rest != "" {
+			return params, &Error{arg[0].Pos, fmt.Sprintf("`...%s` has to be the last argument", params.rest)}
+		}
+
+		if arg[0].Type == Ellipsis {
+			if len(arg) != 2 || arg[1].Type != Ident {
+				return params, &Error{arg[0].Pos, "Expected an argument name after `...`"}
+			}
+			if err := checkNewParam(params, arg[1]); err != nil {
+				return params, err
+			}
+			params.rest = arg[1].Value
+			continue
+		}
+
+		if arg[0].Type != Ident {
+			return params, &Error{arg[0].Pos, fmt.Sprintf("Expected an argument name, but got `%s`", arg[0].Value)}
+		}
+		if err := checkNewParam(params, arg[0]); err != nil {
+			return params, err
+		}
+
+		var defaultValue Node
 		if len(arg) != 1 {
-			return nil, errors.New("Expected comma after argument")
+			if arg[1].Type != Equal {
+				return params, &Error{arg[1].Pos, "Expected comma after argument"}
+			}
+			if len(arg) == 2 {
+				return params, &Error{arg[1].Pos, fmt.Sprintf("Expected a default value after `%s =`", arg[0].Value)}
+			}
+			valueIter := newArrayOfPointerIterator(arg[2:])
+			defaultValue, err = parseExpression(&valueIter)
+			if err != nil {
+				return params, err
+			}
+			if !hasDefaults {
+				params.defaults = make([]Node, i)
+				hasDefaults = true
+			}
+		} else if hasDefaults {
+			return params, &Error{arg[0].Pos, fmt.Sprintf("Argument `%s` needs a default value, because the arguments before it have one", arg[0].Value)}
+		}
+
+		params.names = append(params.names, arg[0].Value)
+		if hasDefaults {
+			params.defaults = append(params.defaults, defaultValue)
 		}
-		argNames = append(argNames, arg[0].Value)
 	}
 
-	return argNames, err
+	return params, err
+}
+
+func checkNewParam(params declParams, nameToken *Token) error {
+	for _, other := range params.names {
+		if other == nameToken.Value {
+			return &Error{nameToken.Pos, fmt.Sprintf("Argument `%s` is declared twice", nameToken.Value)}
+		}
+	}
+	return nil
+}
+
+// `interface Name { method(args) ... }` with a method on every line (or
+// separated by `;`). tokenIter starts at the interface's name
+func parseInterfaceDecl(tokenIter Iterator[Token]) (Node, error) {
+	nameToken, hasName := tokenIter.next()
+	if !hasName || nameToken.Type != Ident {
+		return nil, &Error{tokenIter.prev().Pos, "Expected interface name after `interface`"}
+	}
+	openToken, hasOpen := tokenIter.next()
+	if !hasOpen || openToken.Type != OpenCurlPar {
+		return nil, &Error{tokenIter.prev().Pos, fmt.Sprintf("Expected `{` after `interface %s`", nameToken.Value)}
+	}
+	inner, err := collectBracketed(tokenIter, ClosedCurlPar)
+	if err != nil {
+		return nil, err
+	}
+	if nextToken, hasNext := tokenIter.next(); hasNext {
+		return nil, &Error{nextToken.Pos, fmt.Sprintf("Unexpected `%s` after interface `%s`", nextToken.Value, nameToken.Value)}
+	}
+
+	node := InterfaceDeclNode{Name: nameToken.Value}
+	var signature []*Token
+	for i := 0; i <= len(inner); i++ {
+		if i != len(inner) && inner[i].Type != EOS {
+			// Doc comments of the methods are only for the reader
+			if inner[i].Type != DocComment {
+				signature = append(signature, inner[i])
+			}
+			continue
+		}
+		if len(signature) == 0 {
+			continue
+		}
+
+		method, err := parseInterfaceMethod(signature)
+		if err != nil {
+			return nil, err
+		}
+		for _, other := range node.Methods {
+			if other.Name == method.Name {
+				return nil, &Error{signature[0].Pos, fmt.Sprintf("Method `%s` is declared twice in interface `%s`", method.Name, node.Name)}
+			}
+		}
+		node.Methods = append(node.Methods, method)
+		signature = nil
+	}
+
+	return node, nil
+}
+
+// `name(args)` in an interface
+func parseInterfaceMethod(signature []*Token) (InterfaceMethod, error) {
+	if signature[0].Type != Ident {
+		return InterfaceMethod{}, &Error{signature[0].Pos, fmt.Sprintf("Expected a method name in the interface, but got `%s`", signature[0].Value)}
+	}
+	iter := newArrayOfPointerIterator(signature[1:])
+	params, err := parseFunctionDeclArgs(&iter)
+	if err != nil {
+		return InterfaceMethod{}, err
+	}
+	args := params.names
+	if params.rest != "" {
+		args = append(args, "..."+params.rest)
+	}
+	if nextToken, hasNext := iter.next(); hasNext {
+		return InterfaceMethod{}, &Error{nextToken.Pos, fmt.Sprintf("Unexpected `%s` after method `%s`, interfaces only declare methods", nextToken.Value, signature[0].Value)}
+	}
+	return InterfaceMethod{signature[0].Value, args}, nil
 }
 
 // Parse a block of the form `{` (tokens) `}`
@@ -551,9 +1053,13 @@ func parseBody(tokenIter Iterator[Token]) ([]Node, error) {
 
 	i := 1
 	nextToken, hasNext := tokenIter.next()
+	opening := nextToken
 	for true {
 		if !hasNext {
-			return nil, errors.New("Expected closing curly bracket to match the opening one, but didn't find one")
+			if opening == nil {
+				return nil, errors.New("Expected closing curly bracket to match the opening one, but didn't find one")
+			}
+			return nil, &Error{opening.Pos, "Expected closing curly bracket to match the opening one, but didn't find one"}
 		}
 
 		switch nextToken.Type {
@@ -564,7 +1070,7 @@ func parseBody(tokenIter Iterator[Token]) ([]Node, error) {
 			if curlLevel == 0 {
 				subIter := tokenIter.subslice(i - 2) // don't include last curly brace
 				tokenIter.consume(i - 1)
-				return Parse(subIter.collect())
+				return parseStatements(subIter.collect(), nil)
 			}
 		}
 
@@ -577,6 +1083,7 @@ func parseBody(tokenIter Iterator[Token]) ([]Node, error) {
 
 // Collect a list of arguments between brackets
 func collectList(tokenIter Iterator[Token], closingToken TT) ([][]*Token, error) {
+	opening := tokenIter.prev()
 	parLevel := 0
 	blockLevel := 0 // {}
 	arrayLevel := 0 // []
@@ -608,6 +1115,10 @@ func collectList(tokenIter Iterator[Token], closingToken TT) ([][]*Token, error)
 		} else if nextToken.Type == ClosedSquarePar {
 			arrayLevel -= 1
 		} else if nextToken.Type == Comma && parLevel == 0 && blockLevel == 0 && arrayLevel == 0 {
+			// An empty element, e.g. `f(a,,b)` or `(,a)`
+			if idx == len(tokenArgs) {
+				return nil, &Error{nextToken.Pos, "Unexpected `,`"}
+			}
 			idx += 1
 		}
 		if nextToken.Type != Comma || parLevel != 0 || blockLevel != 0 || arrayLevel != 0 {
@@ -618,21 +1129,5 @@ func collectList(tokenIter Iterator[Token], closingToken TT) ([][]*Token, error)
 		}
 	}
 
-	return nil, errors.New("Invalid list")
-}
-
-func parseStringLiteral(lit string) string {
-	// Remove quotes
-	substr := lit[1 : len(lit)-1]
-	substr = strings.Replace(substr, `\\`, `\`, -1)
-	substr = strings.Replace(substr, `\"`, `"`, -1)
-	substr = strings.Replace(substr, `\n`, "\n", -1)
-	substr = strings.Replace(substr, `\r`, "\r", -1)
-	substr = strings.Replace(substr, `\t`, "\t", -1)
-	substr = strings.Replace(substr, `\a`, "\a", -1)
-	substr = strings.Replace(substr, `\b`, "\b", -1)
-	substr = strings.Replace(substr, `\f`, "\f", -1)
-	substr = strings.Replace(substr, `\v`, "\v", -1)
-	// TODO: \x, \b, \u
-	return substr
+	return nil, &Error{opening.Pos, fmt.Sprintf("Expected `%s` to close `%s`", bracketValue(closingToken), bracketValue(openingBracket(closingToken)))}
 }
@@ -62,15 +62,51 @@ func TestFuncDecl(t *testing.T) {
 		FunctionDeclNode{
 			"test",
 			[]string{"arg"},
+			nil,
+			"",
 			[]Node{
 				VarDeclNode{"argCpy", VariableNode{"arg"}},
 				ReturnNode{VariableNode{"argCpy"}},
 			},
+			"",
 		},
 	}
 	testParsing(source, expected, t)
 }
 
+func TestComments(t *testing.T) {
+	source := "a := /* five */ 5 // trailing\n/* a\nblock */ b := 6"
+	expected := []Node{
+		VarDeclNode{"a", IntegerLiteralNode{5}},
+		VarDeclNode{"b", IntegerLiteralNode{6}},
+	}
+	testParsing(source, expected, t)
+}
+
+func TestDocComment(t *testing.T) {
+	source := `/// Not attached
+a := 1
+/// Greets someone.
+///
+/// Returns nothing
+def greet(name) { noot!(name) /// not a doc comment
+}
+def undocumented() {}`
+	expected := []Node{
+		VarDeclNode{"a", IntegerLiteralNode{1}},
+		FunctionDeclNode{
+			"greet",
+			[]string{"name"},
+			nil,
+			"",
+			[]Node{FunctionCallExprNode{"noot!", []Node{VariableNode{"name"}}}},
+			"Greets someone.\n\nReturns nothing",
+		},
+		FunctionDeclNode{"undocumented", nil, nil, "", []Node{}, ""},
+	}
+	testParsing(source, expected, t)
+}
+
 func TestFuncCallMultiArguments(t *testing.T) {
 	source := "call(a, b)"
 	expected := []Node{
@@ -91,6 +127,8 @@ func TestFuncDeclMultiArguments(t *testing.T) {
 		FunctionDeclNode{
 			"call",
 			[]string{"a", "b"},
+			nil,
+			"",
 			[]Node{
 				ReturnNode{
 					BinaryExpressionNode{
@@ -100,6 +138,7 @@ func TestFuncDeclMultiArguments(t *testing.T) {
 					},
 				},
 			},
+			"",
 		},
 	}
 	testParsing(source, expected, t)
@@ -119,8 +158,8 @@ func TestParseList(t *testing.T) {
 	}
 
 	expected := [][]*Token{
-		{&Token{Ident, "a"}},
-		{&Token{Ident, "b"}},
+		{&Token{Type: Ident, Value: "a"}},
+		{&Token{Type: Ident, Value: "b"}},
 	}
 
 	if len(list) != len(expected) {
@@ -128,7 +167,7 @@ func TestParseList(t *testing.T) {
 	}
 
 	for i := 0; i < len(expected); i++ {
-		if *expected[i][0] != *list[i][0] {
+		if !sameToken(*expected[i][0], *list[i][0]) {
 			t.Fatalf("Unequal element %v an %v", *expected[i][0], *list[i][0])
 		}
 	}
@@ -148,7 +187,7 @@ func TestParseListNested(t *testing.T) {
 	}
 
 	expected := [][]*Token{
-		{&Token{Ident, "add"}, &Token{OpenPar, "("}, &Token{Ident, "a"}, &Token{Comma, ","}, &Token{Ident, "b"}},
+		{&Token{Type: Ident, Value: "add"}, &Token{Type: OpenPar, Value: "("}, &Token{Type: Ident, Value: "a"}, &Token{Type: Comma, Value: ","}, &Token{Type: Ident, Value: "b"}},
 	}
 
 	if len(list) != len(expected) {
@@ -182,6 +221,73 @@ func TestParseString(t *testing.T) {
 	testParsing(source, expected, t)
 }
 
+func TestParseStringEscapes(t *testing.T) {
+	source := `a := "\\n \"q\" \t \x41 \u00e9 \U0001F427 \{"`
+	expected := []Node{
+		VarDeclNode{"a", StringLiteralNode{"\\n \"q\" \t A é 🐧 {"}},
+	}
+	testParsing(source, expected, t)
+}
+
+func TestParseInvalidEscape(t *testing.T) {
+	for _, source := range []string{`"\q"`, `"\x4"`, `"\uZZZZ"`, `"\UFFFFFFFF"`, `"\xff"`, `"\xff{a}"`, `"{a}\xff"`, `"{a"`, `"a}"`, `"{ }"`} {
+		tokens, err := Tokenize("a := " + source)
+		if err != nil {
+			continue // `"{a"` doesn't even tokenize, the string is unterminated
+		}
+		if _, err := Parse(tokens); err == nil {
+			t.Fatalf("Expected an error for %s", source)
+		}
+	}
+}
+
+// `{}` and `{:spec}` are left for `format`
+func TestParseFormatPlaceholders(t *testing.T) {
+	source := `a := "{} has {:>5} {:.2} {n}"`
+	expected := []Node{
+		VarDeclNode{"a", InterpolatedStringNode{[]Node{
+			StringLiteralNode{"{} has {:>5} {:.2} "},
+			VariableNode{"n"},
+		}}},
+	}
+	testParsing(source, expected, t)
+}
+
+func TestParseRawString(t *testing.T) {
+	source := "a := `no \\n escapes\nor {interpolation}`"
+	expected := []Node{
+		VarDeclNode{"a", StringLiteralNode{"no \\n escapes\nor {interpolation}"}},
+	}
+	testParsing(source, expected, t)
+}
+
+func TestParseInterpolation(t *testing.T) {
+	source := `a := "hello {name}, you have {n + 1} msgs"`
+	expected := []Node{
+		VarDeclNode{"a", InterpolatedStringNode{
+			[]Node{
+				StringLiteralNode{"hello "},
+				VariableNode{"name"},
+				StringLiteralNode{", you have "},
+				BinaryExpressionNode{VariableNode{"n"}, Operator("+"), IntegerLiteralNode{1}},
+				StringLiteralNode{" msgs"},
+			},
+		}},
+	}
+	testParsing(source, expected, t)
+}
+
+func TestParseRepetitionInString(t *testing.T) {
+	source := `a := regex("a{3}b{2,}c{1,5}{n}")`
+	expected := []Node{
+		VarDeclNode{"a", FunctionCallExprNode{"regex", []Node{InterpolatedStringNode{[]Node{
+			StringLiteralNode{"a{3}b{2,}c{1,5}"},
+			VariableNode{"n"},
+		}}}}},
+	}
+	testParsing(source, expected, t)
+}
+
 func TestParseFloat(t *testing.T) {
 	source := "a := 6.5"
 	expected := []Node{
@@ -368,24 +474,325 @@ func TestMethodOnString(t *testing.T) {
 }
 
 func TestMethodOnInt(t *testing.T) {
-	// TODO: fix tokenization for this case
-	// fmt.Println("--------")
-	// source := `a := 6.concat(9)`
-	// expected := []Node{
-	// 	VarDeclNode{
-	// 		"a",
-	// 		MethodCallExprNode{
-	// 			IntegerLiteralNode{6},
-	// 			FunctionCallExprNode{
-	// 				"concat",
-	// 				[]Node{
-	// 					IntegerLiteralNode{9},
-	// 				},
-	// 			},
-	// 		},
-	// 	},
-	// }
-	// testParsing(source, expected, t)
+	source := `a := 6.concat(9)`
+	expected := []Node{
+		VarDeclNode{
+			"a",
+			MethodCallExprNode{
+				IntegerLiteralNode{6},
+				FunctionCallExprNode{
+					"concat",
+					[]Node{
+						IntegerLiteralNode{9},
+					},
+				},
+			},
+		},
+	}
+	testParsing(source, expected, t)
+}
+
+func TestMethodChain(t *testing.T) {
+	source := `a.trim().split(",")[0].len()`
+	expected := []Node{
+		MethodCallExprNode{
+			ArrayIndexNode{
+				MethodCallExprNode{
+					MethodCallExprNode{VariableNode{"a"}, FunctionCallExprNode{"trim", nil}},
+					FunctionCallExprNode{"split", []Node{StringLiteralNode{","}}},
+				},
+				IntegerLiteralNode{0},
+			},
+			FunctionCallExprNode{"len", nil},
+		},
+	}
+	testParsing(source, expected, t)
+}
+
+func TestFieldAccess(t *testing.T) {
+	source := `x := math.pi * 2`
+	expected := []Node{
+		VarDeclNode{
+			"x",
+			BinaryExpressionNode{
+				FieldAccessNode{VariableNode{"math"}, "pi"},
+				Operator("*"),
+				IntegerLiteralNode{2},
+			},
+		},
+	}
+	testParsing(source, expected, t)
+}
+
+func TestCallInBinaryExpression(t *testing.T) {
+	source := `x := f(1) + a.b(2)`
+	expected := []Node{
+		VarDeclNode{
+			"x",
+			BinaryExpressionNode{
+				FunctionCallExprNode{"f", []Node{IntegerLiteralNode{1}}},
+				Operator("+"),
+				MethodCallExprNode{VariableNode{"a"}, FunctionCallExprNode{"b", []Node{IntegerLiteralNode{2}}}},
+			},
+		},
+	}
+	testParsing(source, expected, t)
+}
+
+func TestLeftAssociativity(t *testing.T) {
+	source := `x := 8 - 4 - 2 / 2 * 3`
+	expected := []Node{
+		VarDeclNode{
+			"x",
+			BinaryExpressionNode{
+				BinaryExpressionNode{IntegerLiteralNode{8}, Operator("-"), IntegerLiteralNode{4}},
+				Operator("-"),
+				BinaryExpressionNode{
+					BinaryExpressionNode{IntegerLiteralNode{2}, Operator("/"), IntegerLiteralNode{2}},
+					Operator("*"),
+					IntegerLiteralNode{3},
+				},
+			},
+		},
+	}
+	testParsing(source, expected, t)
+}
+
+func TestPrefixOperators(t *testing.T) {
+	source := `x := -a * -(1 + 2) && !b`
+	negate := func(node Node) Node {
+		return BinaryExpressionNode{IntegerLiteralNode{0}, Operator("-"), node}
+	}
+	expected := []Node{
+		VarDeclNode{
+			"x",
+			BinaryExpressionNode{
+				BinaryExpressionNode{
+					negate(VariableNode{"a"}),
+					Operator("*"),
+					negate(BinaryExpressionNode{IntegerLiteralNode{1}, Operator("+"), IntegerLiteralNode{2}}),
+				},
+				Operator("&&"),
+				BinaryNotNode{VariableNode{"b"}},
+			},
+		},
+	}
+	testParsing(source, expected, t)
+}
+
+func TestMultipleSyntaxErrors(t *testing.T) {
+	source := `a := 1
+b := )
+noot!(a)
+def f(x) {
+  y := )
+  return x
+}
+c := [1,
+while {}`
+	tokens, err := Tokenize(source)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	nodes, err := Parse(tokens)
+	errs, isErrorList := err.(ErrorList)
+	if !isErrorList {
+		t.Fatalf("Expected an ErrorList, but got %#v", err)
+	}
+
+	expectedLines := []int{2, 5, 8}
+	if len(errs) != len(expectedLines) {
+		t.Fatalf("Expected %d errors, but got:\n%v", len(expectedLines), errs)
+	}
+	for i, line := range expectedLines {
+		if errs[i].Pos.Line != line {
+			t.Fatalf("Expected error %d on line %d, but got %v", i, line, errs[i])
+		}
+	}
+
+	// The valid statements and the function with an error in its body are kept
+	expected := []Node{
+		VarDeclNode{"a", IntegerLiteralNode{1}},
+		FunctionCallExprNode{"noot!", []Node{VariableNode{"a"}}},
+		FunctionDeclNode{"f", []string{"x"}, nil, "", []Node{ReturnNode{VariableNode{"x"}}}, ""},
+	}
+	if !reflect.DeepEqual(nodes, expected) {
+		t.Fatalf("Expected partial AST %#v\n But got %#v\n", expected, nodes)
+	}
+}
+
+// Errors are reported at the token that caused them, not at the start of the
+// statement
+func TestSyntaxErrorPositions(t *testing.T) {
+	for source, expected := range map[string]string{
+		"a := := 1":               "1:6: Invalid start of expression `:=`",
+		"x := 1 2":                "1:8: Unexpected `2` in expression",
+		"a := 1 +":                "1:8: Expected an expression after `+`",
+		"a := [1, 2":              "1:6: Expected `]` to close `[`",
+		"a := foo(1, b = 2, 3)":   "1:20: Positional arguments have to come before named arguments",
+		"def f(a, a) {}":          "1:10: Argument `a` is declared twice",
+		"a b":                     "1:3: Unexpected `b`",
+		"f(a,,b)":                 "1:5: Unexpected `,`",
+		"def f(,a) {}":            "1:7: Unexpected `,`",
+		"a := [,]":                "1:7: Unexpected `,`",
+		"a := !":                  "1:6: Expected an expression after `!`",
+		"x := 1 * -":              "1:10: Expected an expression after `-`",
+		"[a, 1] := b":             "1:5: Expected a comma separated list of names to destructure into",
+		"if x {\n  a := b.c.\n}":  "2:11: Expected a name after `.`",
+		"while x {\n  noot!(1)\n": "1:9: Expected closing curly bracket to match the opening one, but didn't find one",
+	} {
+		tokens, err := Tokenize(source)
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+		_, err = Parse(tokens)
+		if err == nil || err.Error() != expected {
+			t.Errorf("Expected `%s` for %q, but got %v", expected, source, err)
+		}
+	}
+}
+
+// A statement with an empty element doesn't stop the statements after it from
+// being parsed
+func TestEmptyListElement(t *testing.T) {
+	tokens, err := Tokenize("f(a,,b)\ndef g(,a) {}\nh(a,)")
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	nodes, err := Parse(tokens)
+	if errs, isErrorList := err.(ErrorList); !isErrorList || len(errs) != 2 {
+		t.Fatalf("Expected two errors, but got %v", err)
+	}
+	if !reflect.DeepEqual(nodes, []Node{FunctionCallExprNode{"h", []Node{VariableNode{"a"}}}}) {
+		t.Fatalf("Expected the last statement to be parsed, but got %#v", nodes)
+	}
+}
+
+func TestStrayClosingBracket(t *testing.T) {
+	tokens, err := Tokenize("a := 1 }\nb := 2")
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	nodes, err := Parse(tokens)
+	if errs, isErrorList := err.(ErrorList); !isErrorList || len(errs) != 1 {
+		t.Fatalf("Expected one error, but got %v", err)
+	}
+	if !reflect.DeepEqual(nodes, []Node{VarDeclNode{"b", IntegerLiteralNode{2}}}) {
+		t.Fatalf("Expected the second statement to be parsed, but got %#v", nodes)
+	}
+}
+
+func TestMultiLineCall(t *testing.T) {
+	source := `noot!(
+	add(a,
+		b),
+	[
+		1,
+		2
+	]
+)`
+	expected := []Node{
+		FunctionCallExprNode{"noot!", []Node{
+			FunctionCallExprNode{"add", []Node{VariableNode{"a"}, VariableNode{"b"}}},
+			ArrayLiteralNode{[]Node{IntegerLiteralNode{1}, IntegerLiteralNode{2}}},
+		}},
+	}
+	testParsing(source, expected, t)
+}
+
+func TestMultiLineFuncDecl(t *testing.T) {
+	source := `def add(
+	a,
+	b
+) {
+	return a +
+		b
+}`
+	expected := []Node{
+		FunctionDeclNode{
+			"add",
+			[]string{"a", "b"},
+			nil,
+			"",
+			[]Node{ReturnNode{BinaryExpressionNode{VariableNode{"a"}, Operator("+"), VariableNode{"b"}}}},
+			"",
+		},
+	}
+	testParsing(source, expected, t)
+}
+
+func TestTrailingOperator(t *testing.T) {
+	source := `a :=
+	1 +
+	2 *
+	3
+b := a ==
+	7 &&
+	!false`
+	expected := []Node{
+		VarDeclNode{"a", BinaryExpressionNode{
+			IntegerLiteralNode{1},
+			Operator("+"),
+			BinaryExpressionNode{IntegerLiteralNode{2}, Operator("*"), IntegerLiteralNode{3}},
+		}},
+		VarDeclNode{"b", BinaryExpressionNode{
+			BinaryExpressionNode{VariableNode{"a"}, Operator("=="), IntegerLiteralNode{7}},
+			Operator("&&"),
+			BinaryNotNode{BoolLiteralNode{false}},
+		}},
+	}
+	testParsing(source, expected, t)
+}
+
+func TestElseOnNewLine(t *testing.T) {
+	source := `if a {
+	x := 1
+}
+elsif b {
+	x := 2
+}
+
+else {
+	x := 3
+}
+y := 4`
+	expected := []Node{
+		IfNode{
+			VariableNode{"a"},
+			IfNode{
+				VariableNode{"b"},
+				ElseNode{[]Node{VarDeclNode{"x", IntegerLiteralNode{3}}}},
+				[]Node{VarDeclNode{"x", IntegerLiteralNode{2}}},
+			},
+			[]Node{VarDeclNode{"x", IntegerLiteralNode{1}}},
+		},
+		VarDeclNode{"y", IntegerLiteralNode{4}},
+	}
+	testParsing(source, expected, t)
+}
+
+func TestMethodChainOnNewLine(t *testing.T) {
+	source := `s
+	.concat("!")
+t := 1`
+	expected := []Node{
+		MethodCallExprNode{VariableNode{"s"}, FunctionCallExprNode{"concat", []Node{StringLiteralNode{"!"}}}},
+		VarDeclNode{"t", IntegerLiteralNode{1}},
+	}
+	testParsing(source, expected, t)
+}
+
+func TestNewLineEndsStatement(t *testing.T) {
+	source := "a := 1\nb := a\n\nnoot!(b)\n"
+	expected := []Node{
+		VarDeclNode{"a", IntegerLiteralNode{1}},
+		VarDeclNode{"b", VariableNode{"a"}},
+		FunctionCallExprNode{"noot!", []Node{VariableNode{"b"}}},
+	}
+	testParsing(source, expected, t)
 }
 
 func testParsing(source string, expected []Node, t *testing.T) {
@@ -410,3 +817,140 @@ func testParsing(source string, expected []Node, t *testing.T) {
 		}
 	}
 }
+
+func TestNamedArguments(t *testing.T) {
+	source := `noot!(a, b, sep = ", ", end = "")`
+	expected := []Node{
+		FunctionCallExprNode{"noot!", []Node{
+			VariableNode{"a"},
+			VariableNode{"b"},
+			NamedArgumentNode{"sep", StringLiteralNode{", "}},
+			NamedArgumentNode{"end", StringLiteralNode{""}},
+		}},
+	}
+	testParsing(source, expected, t)
+
+	tokens, _ := Tokenize(`noot!(sep = "", a)`)
+	if _, err := Parse(tokens); err == nil {
+		t.Fatal("Expected an error for a positional argument after a named one")
+	}
+}
+
+func TestTupleLiterals(t *testing.T) {
+	source := "a := (1, (2,), ())\nreturn a, [b, c]"
+	expected := []Node{
+		VarDeclNode{"a", TupleLiteralNode{[]Node{
+			IntegerLiteralNode{1},
+			TupleLiteralNode{[]Node{IntegerLiteralNode{2}}},
+			TupleLiteralNode{[]Node{}},
+		}}},
+		ReturnNode{TupleLiteralNode{[]Node{
+			VariableNode{"a"},
+			ArrayLiteralNode{[]Node{VariableNode{"b"}, VariableNode{"c"}}},
+		}}},
+	}
+	testParsing(source, expected, t)
+}
+
+func TestDestructuringDecl(t *testing.T) {
+	source := "a, _ := f(x, y)\n[b, c] := 1, 2"
+	expected := []Node{
+		DestructuringDeclNode{[]string{"a", "_"}, FunctionCallExprNode{"f", []Node{VariableNode{"x"}, VariableNode{"y"}}}},
+		DestructuringDeclNode{[]string{"b", "c"}, TupleLiteralNode{[]Node{IntegerLiteralNode{1}, IntegerLiteralNode{2}}}},
+	}
+	testParsing(source, expected, t)
+
+	for _, source := range []string{"a, 1 := x", "a, b = x", "[a, b.c] := x", "(a, b)"} {
+		tokens, _ := Tokenize(source)
+		if _, err := Parse(tokens); err == nil {
+			t.Errorf("Expected an error for %s", source)
+		}
+	}
+}
+
+func TestInterfaceDecl(t *testing.T) {
+	source := "/// A shape\ninterface Shape {\n  area()\n  /// Multiplies the size\n  scale(factor); move(x, y)\n}\ninterface Any {}"
+	expected := []Node{
+		InterfaceDeclNode{"Shape", []InterfaceMethod{{"area", nil}, {"scale", []string{"factor"}}, {"move", []string{"x", "y"}}}, "A shape"},
+		InterfaceDeclNode{"Any", nil, ""},
+	}
+	testParsing(source, expected, t)
+
+	for _, source := range []string{"interface { area() }", "interface Shape", "interface Shape { area() {} }", "interface Shape { area(); area() }", "interface Shape { 1 }", "interface Shape {} x"} {
+		tokens, _ := Tokenize(source)
+		if _, err := Parse(tokens); err == nil {
+			t.Errorf("Expected an error for %s", source)
+		}
+	}
+}
+
+func TestTypeAssertions(t *testing.T) {
+	source := "a := x is Shape == true\nb := x as Shape * 2"
+	expected := []Node{
+		VarDeclNode{"a", BinaryExpressionNode{BinaryExpressionNode{VariableNode{"x"}, Op_Is, VariableNode{"Shape"}}, Op_CompEqual, BoolLiteralNode{true}}},
+		VarDeclNode{"b", BinaryExpressionNode{BinaryExpressionNode{VariableNode{"x"}, Op_As, VariableNode{"Shape"}}, Op_Mul, IntegerLiteralNode{2}}},
+	}
+	testParsing(source, expected, t)
+}
+
+func TestFuncDeclDefaults(t *testing.T) {
+	source := "def f(a, b = 1, ...rest) {}\ndef g(...rest) {}"
+	expected := []Node{
+		FunctionDeclNode{"f", []string{"a", "b"}, []Node{nil, IntegerLiteralNode{1}}, "rest", []Node{}, ""},
+		FunctionDeclNode{"g", nil, nil, "rest", []Node{}, ""},
+	}
+	testParsing(source, expected, t)
+
+	for _, source := range []string{"def f(a = 1, b) {}", "def f(...rest, a) {}", "def f(a, a) {}", "def f(a, ...a) {}", "def f(...) {}", "def f(a =) {}", "def f(1) {}", "def f(a b) {}"} {
+		tokens, _ := Tokenize(source)
+		if _, err := Parse(tokens); err == nil {
+			t.Errorf("Expected an error for %s", source)
+		}
+	}
+}
+
+func TestCallExpressions(t *testing.T) {
+	source := "f(1)(2)\na[0](x)\nb := g()[1](y)"
+	expected := []Node{
+		CallExprNode{FunctionCallExprNode{"f", []Node{IntegerLiteralNode{1}}}, []Node{IntegerLiteralNode{2}}},
+		CallExprNode{ArrayIndexNode{VariableNode{"a"}, IntegerLiteralNode{0}}, []Node{VariableNode{"x"}}},
+		VarDeclNode{"b", CallExprNode{ArrayIndexNode{FunctionCallExprNode{"g", nil}, IntegerLiteralNode{1}}, []Node{VariableNode{"y"}}}},
+	}
+	testParsing(source, expected, t)
+}
+
+func TestConstDecl(t *testing.T) {
+	source := "const MAX = 10\ndef f(MAX) { MAX = 1 }\ndef g() { MAX := 2; MAX += 1 }"
+	expected := []Node{
+		ConstDeclNode{"MAX", IntegerLiteralNode{10}},
+		FunctionDeclNode{"f", []string{"MAX"}, nil, "", []Node{VarAssignNode{"MAX", Op_Equal, IntegerLiteralNode{1}}}, ""},
+		FunctionDeclNode{"g", nil, nil, "", []Node{VarDeclNode{"MAX", IntegerLiteralNode{2}}, VarAssignNode{"MAX", Op_PlusEqual, IntegerLiteralNode{1}}}, ""},
+	}
+	testParsing(source, expected, t)
+
+	for _, source := range []string{
+		"const X = 1\nX = 2",
+		"const X = 1\nX += 1",
+		"const X = [1]\nX[0] = 2",
+		"const X = 1\nif true { X -= 1 } else { X = 0 }",
+		"const X = 1\nwhile true { X *= 2 }",
+		"const",
+		"const X := 1",
+		"const X",
+	} {
+		tokens, _ := Tokenize(source)
+		if _, err := Parse(tokens); err == nil {
+			t.Errorf("Expected an error for %s", source)
+		}
+	}
+
+	tokens, _ := Tokenize("const X = 1\ndef f() {\n  y := X\n  X = 2\n}")
+	_, err := Parse(tokens)
+	errs, isErrorList := err.(ErrorList)
+	if !isErrorList || len(errs) != 1 {
+		t.Fatalf("Expected 1 error, but got %v", err)
+	}
+	if errs[0].Pos != (Position{4, 3}) || errs[0].Msg != "Cannot assign to constant `X`" {
+		t.Errorf("Got %v", errs[0])
+	}
+}
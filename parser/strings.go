@@ -0,0 +1,228 @@
+package parser
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// Parse a string token into a `StringLiteralNode`, or an `InterpolatedStringNode`
+// when the string contains `{expr}` parts.
+//
+// `{}` and `{:spec}` are not expressions, they are kept as placeholders for
+// `format`. Neither are regex repetitions (`{3}`, `{2,}` or `{2,5}`), which are
+// kept as they are.
+//
+// Raw strings (`...`) are taken as is. Regular strings ("...") support the
+// following escape sequences:
+//
+//	\\ \" \{ \} \n \r \t \a \b \f \v \0
+//	\xHH       a byte (must form valid UTF-8 with the bytes around it)
+//	\uHHHH     a unicode code point
+//	\UHHHHHHHH a unicode code point
+func parseStringLiteral(token *Token) (Node, error) {
+	lit := token.Value
+	if lit[0] == '`' {
+		return StringLiteralNode{lit[1 : len(lit)-1]}, nil
+	}
+
+	// Remove quotes
+	source := lit[1 : len(lit)-1]
+
+	var parts []Node
+	var str strings.Builder
+	for i := 0; i < len(source); {
+		switch source[i] {
+		case '\\':
+			decoded, n, err := decodeEscape(source[i:])
+			if err != nil {
+				return nil, &Error{token.Pos, err.Error()}
+			}
+			str.WriteString(decoded)
+			i += n
+		case '{':
+			end := matchingBrace(source, i)
+			if end == -1 {
+				return nil, &Error{token.Pos, "Unclosed `{` in string, use `\\{` for a literal curly bracket"}
+			}
+			if isFormatPlaceholder(source[i+1:end]) || isRepetition(source[i+1:end]) {
+				str.WriteString(source[i : end+1])
+				i = end + 1
+				continue
+			}
+			expr, err := parseInterpolation(source[i+1 : end])
+			if err != nil {
+				return nil, &Error{token.Pos, fmt.Sprintf("In string interpolation: %s", err.Error())}
+			}
+			if str.Len() != 0 {
+				part, err := literalPart(token, str.String())
+				if err != nil {
+					return nil, err
+				}
+				parts = append(parts, part)
+				str.Reset()
+			}
+			parts = append(parts, expr)
+			i = end + 1
+		case '}':
+			return nil, &Error{token.Pos, "Unmatched `}` in string, use `\\}` for a literal curly bracket"}
+		default:
+			str.WriteByte(source[i])
+			i += 1
+		}
+	}
+
+	if len(parts) == 0 {
+		return literalPart(token, str.String())
+	}
+	if str.Len() != 0 {
+		part, err := literalPart(token, str.String())
+		if err != nil {
+			return nil, err
+		}
+		parts = append(parts, part)
+	}
+	return InterpolatedStringNode{parts}, nil
+}
+
+// A part of the string between interpolations. `\xHH` escapes can make it
+// invalid UTF-8, which is an error
+func literalPart(token *Token, str string) (Node, error) {
+	if !utf8.ValidString(str) {
+		return nil, &Error{token.Pos, "String contains invalid UTF-8"}
+	}
+	return StringLiteralNode{str}, nil
+}
+
+// Whether the contents of `{...}` are a `format` placeholder, `{}` or `{:spec}`
+func isFormatPlaceholder(contents string) bool {
+	return contents == "" || strings.HasPrefix(contents, ":")
+}
+
+// Whether the contents of `{...}` are a regex repetition, `n`, `n,` or `n,m`
+func isRepetition(contents string) bool {
+	min, max, hasMax := strings.Cut(contents, ",")
+	return isDigits(min) && (!hasMax || max == "" || isDigits(max))
+}
+
+func isDigits(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// Decodes the escape sequence at the start of `source`. Returns the decoded
+// string and the amount of bytes that were read
+func decodeEscape(source string) (string, int, error) {
+	if len(source) < 2 {
+		return "", 0, errors.New("Unfinished escape sequence at the end of the string")
+	}
+
+	switch source[1] {
+	case '\\':
+		return "\\", 2, nil
+	case '"':
+		return "\"", 2, nil
+	case '{':
+		return "{", 2, nil
+	case '}':
+		return "}", 2, nil
+	case 'n':
+		return "\n", 2, nil
+	case 'r':
+		return "\r", 2, nil
+	case 't':
+		return "\t", 2, nil
+	case 'a':
+		return "\a", 2, nil
+	case 'b':
+		return "\b", 2, nil
+	case 'f':
+		return "\f", 2, nil
+	case 'v':
+		return "\v", 2, nil
+	case '0':
+		return "\x00", 2, nil
+	case 'x':
+		value, err := parseHexDigits(source, 2)
+		if err != nil {
+			return "", 0, err
+		}
+		return string([]byte{byte(value)}), 4, nil
+	case 'u', 'U':
+		digits := 4
+		if source[1] == 'U' {
+			digits = 8
+		}
+		value, err := parseHexDigits(source, digits)
+		if err != nil {
+			return "", 0, err
+		}
+		if !utf8.ValidRune(rune(value)) {
+			return "", 0, errors.New(fmt.Sprintf("`%s` is not a valid unicode code point", source[:2+digits]))
+		}
+		return string(rune(value)), 2 + digits, nil
+	default:
+		r, _ := utf8.DecodeRuneInString(source[1:])
+		return "", 0, errors.New(fmt.Sprintf("Invalid escape sequence `\\%c`", r))
+	}
+}
+
+// Parses the `digits` hexadecimal digits following an escape sequence's
+// letter (`\x`, `\u` or `\U`)
+func parseHexDigits(source string, digits int) (uint64, error) {
+	if len(source) < 2+digits {
+		return 0, errors.New(fmt.Sprintf("Escape sequence `\\%c` expects %d hexadecimal digits", source[1], digits))
+	}
+	value, err := strconv.ParseUint(source[2:2+digits], 16, 32)
+	if err != nil {
+		return 0, errors.New(fmt.Sprintf("Escape sequence `\\%c` expects %d hexadecimal digits, got `%s`", source[1], digits, source[2:2+digits]))
+	}
+	return value, nil
+}
+
+// Returns the index of the `}` matching the `{` at `start`, or -1 if there is
+// none. Strings inside of the braces are skipped, so they can contain braces
+func matchingBrace(source string, start int) int {
+	braceLevel := 0
+	inString := false
+	for i := start; i < len(source); i++ {
+		switch {
+		case source[i] == '\\':
+			i += 1 // skip escaped character
+		case source[i] == '"':
+			inString = !inString
+		case inString:
+			// not a brace
+		case source[i] == '{':
+			braceLevel += 1
+		case source[i] == '}':
+			braceLevel -= 1
+			if braceLevel == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// Parse the expression inside of `{}` in a string
+func parseInterpolation(source string) (Node, error) {
+	tokens, err := Tokenize(source)
+	if err != nil {
+		return nil, err
+	}
+	if len(tokens) == 0 {
+		return nil, errors.New("Empty expression in `{}`")
+	}
+	iter := newArrayIterator(tokens)
+	return parseExpression(&iter)
+}
@@ -1,10 +1,11 @@
 package parser
 
 import (
-	"errors"
+	"bufio"
 	"fmt"
-	"regexp"
+	"io"
 	"strings"
+	"unicode"
 )
 
 // Token Type
@@ -21,7 +22,7 @@ const (
 	SlashEqual    // /=
 	Float         // \d.\d
 	Integer       // \d
-	String        // ".* \" "
+	String        // ".* \" " or `...`
 	Bool          // true false
 
 	// Binary operators
@@ -33,6 +34,8 @@ const (
 	GT      // >
 	LTE     // <=
 	GTE     // >=
+	Is      // is
+	As      // as
 	Plus    // +
 	Minus   // -
 	Slash   // /
@@ -56,104 +59,473 @@ const (
 	Else            // else
 	Elsif           // elsif
 	While           // while
+	Interface       // interface
+	Const           // const
 	Dot             // .
+	Ellipsis        // ...
 
-	Comment // //...
+	Comment    // //... or /* ... */
+	DocComment // ///...
 )
 
+// Names of the token types, for printing tokens
+var tokenTypeNames = map[TT]string{
+	Ident:           "Ident",
+	Declare:         "Declare",
+	Equal:           "Equal",
+	PlusEqual:       "PlusEqual",
+	MinEqual:        "MinEqual",
+	StarEqual:       "StarEqual",
+	SlashEqual:      "SlashEqual",
+	Float:           "Float",
+	Integer:         "Integer",
+	String:          "String",
+	Bool:            "Bool",
+	And:             "And",
+	Or:              "Or",
+	DEqual:          "DEqual",
+	DNEqual:         "DNEqual",
+	LT:              "LT",
+	GT:              "GT",
+	LTE:             "LTE",
+	GTE:             "GTE",
+	Is:              "Is",
+	As:              "As",
+	Plus:            "Plus",
+	Minus:           "Minus",
+	Slash:           "Slash",
+	Star:            "Star",
+	Not:             "Not",
+	EOS:             "EOS",
+	OpenPar:         "OpenPar",
+	ClosedPar:       "ClosedPar",
+	OpenCurlPar:     "OpenCurlPar",
+	ClosedCurlPar:   "ClosedCurlPar",
+	OpenSquarePar:   "OpenSquarePar",
+	ClosedSquarePar: "ClosedSquarePar",
+	Comma:           "Comma",
+	Def:             "Def",
+	Return:          "Return",
+	Nil:             "Nil",
+	If:              "If",
+	Else:            "Else",
+	Elsif:           "Elsif",
+	While:           "While",
+	Interface:       "Interface",
+	Const:           "Const",
+	Dot:             "Dot",
+	Ellipsis:        "Ellipsis",
+	Comment:         "Comment",
+	DocComment:      "DocComment",
+}
+
+// The name of a token type (e.g. `Ident`)
+func TokenTypeName(ty TT) string {
+	if name, ok := tokenTypeNames[ty]; ok {
+		return name
+	}
+	return fmt.Sprintf("TT(%d)", ty)
+}
+
+// A position in the source code. Lines and columns start at 1, columns are
+// counted in runes
+type Position struct {
+	Line   int
+	Column int
+}
+
+func (pos Position) String() string {
+	return fmt.Sprintf("%d:%d", pos.Line, pos.Column)
+}
+
 // A single token
 type Token struct {
 	Type  TT
 	Value string
+	// Where the token starts in the source
+	Pos Position
+}
+
+// Words that are tokenized as something other than an identifier
+var keywords = map[string]TT{
+	"def":       Def,
+	"return":    Return,
+	"nil":       Nil,
+	"if":        If,
+	"else":      Else,
+	"elsif":     Elsif,
+	"while":     While,
+	"interface": Interface,
+	"const":     Const,
+	"is":        Is,
+	"as":        As,
+	"true":      Bool,
+	"false":     Bool,
 }
 
-// Pair of token type and its regex definition
-type Pair struct {
+// Operators and punctuation, the longest operators are matched first
+var operators = []struct {
+	Value string
 	Type  TT
-	Regex *regexp.Regexp
+}{
+	{":=", Declare},
+	{"==", DEqual},
+	{"!=", DNEqual},
+	{"+=", PlusEqual},
+	{"-=", MinEqual},
+	{"*=", StarEqual},
+	{"/=", SlashEqual},
+	{"<=", LTE},
+	{">=", GTE},
+	{"&&", And},
+	{"||", Or},
+	{"<", LT},
+	{">", GT},
+	{"=", Equal},
+	{"+", Plus},
+	{"-", Minus},
+	{"*", Star},
+	{"/", Slash},
+	{"!", Not},
+	{";", EOS},
+	{"(", OpenPar},
+	{")", ClosedPar},
+	{"{", OpenCurlPar},
+	{"}", ClosedCurlPar},
+	{"[", OpenSquarePar},
+	{"]", ClosedSquarePar},
+	{",", Comma},
+	{"...", Ellipsis},
+	{".", Dot},
 }
 
 // Source code to tokens
 func Tokenize(source string) ([]Token, error) {
-	// Token regex definitions
-	// The first in the list is the one that is matched first
-	re := []Pair{
-		{Comment, regexp.MustCompile(`\A(//.*)`)},
-		{Declare, regexp.MustCompile(`\A(:=)`)},
-		{DEqual, regexp.MustCompile(`\A(==)`)},
-		{PlusEqual, regexp.MustCompile(`\A(\+=)`)},
-		{MinEqual, regexp.MustCompile(`\A(-=)`)},
-		{StarEqual, regexp.MustCompile(`\A(\*=)`)},
-		{SlashEqual, regexp.MustCompile(`\A(/=)`)},
-		{LTE, regexp.MustCompile(`\A(<=)`)},
-		{GTE, regexp.MustCompile(`\A(>=)`)},
-		{LT, regexp.MustCompile(`\A(<)`)},
-		{GT, regexp.MustCompile(`\A(>)`)},
-		{Equal, regexp.MustCompile(`\A(=)`)},
-		{Plus, regexp.MustCompile(`\A\+`)},
-		{Minus, regexp.MustCompile(`\A-`)},
-		{Star, regexp.MustCompile(`\A\*`)},
-		{Slash, regexp.MustCompile(`\A/`)},
-		{Float, regexp.MustCompile(`\A\d+\.\d*`)},
-		{Integer, regexp.MustCompile(`\A\b\d+\b`)},
-		{String, regexp.MustCompile(`\A"[^"\\]*(\\.[^"\\]*)*"`)},
-		{Bool, regexp.MustCompile(`\A(true|false)`)},
-		{DNEqual, regexp.MustCompile(`\A(!=)`)},
-		{And, regexp.MustCompile(`\A(&&)`)},
-		{Or, regexp.MustCompile(`\A(\|\|)`)},
-		{Not, regexp.MustCompile(`\A(!)`)},
-		{EOS, regexp.MustCompile(`\A(\n|;)`)},
-		{OpenPar, regexp.MustCompile(`\A\(`)},
-		{ClosedPar, regexp.MustCompile(`\A\)`)},
-		{OpenCurlPar, regexp.MustCompile(`\A{`)},
-		{ClosedCurlPar, regexp.MustCompile(`\A}`)},
-		{OpenSquarePar, regexp.MustCompile(`\A\[`)},
-		{ClosedSquarePar, regexp.MustCompile(`\A\]`)},
-		{Comma, regexp.MustCompile(`\A(,)`)},
-		{Dot, regexp.MustCompile(`\A(\.)`)},
-		{Def, regexp.MustCompile(`\A(def)`)},
-		{Return, regexp.MustCompile(`\A(return)`)},
-		{Nil, regexp.MustCompile(`\A(nil)`)},
-		{If, regexp.MustCompile(`\A(if)`)},
-		{Else, regexp.MustCompile(`\A(else)`)},
-		{Elsif, regexp.MustCompile(`\A(elsif)`)},
-		{While, regexp.MustCompile(`\A(while)`)},
-		{Ident, regexp.MustCompile(`\A(\w|!|\?)+`)},
-	}
-
-	source = strings.TrimSpace(source)
-	source = strings.Replace(source, "\t", "", -1)
-	// source = strings.Replace(source, "\n", "", -1)
+	return TokenizeReader(strings.NewReader(source))
+}
+
+// Read all tokens from `reader`
+func TokenizeReader(reader io.Reader) ([]Token, error) {
+	scanner := NewScanner(reader)
 
 	// Collect tokens
 	var tokens []Token
-	for source != "" {
-		token, err := nextToken(&source, &re)
+	for {
+		token, err := scanner.Next()
+		if err == io.EOF {
+			return tokens, nil
+		}
 		if err != nil {
 			return nil, err
 		}
-		tokens = append(tokens, *token)
+		tokens = append(tokens, token)
+	}
+}
+
+// Scanner reads tokens from a stream of source code one rune at a time
+type Scanner struct {
+	reader *bufio.Reader
+	// Runes that have been peeked at, `lookahead[head:]` haven't been consumed yet
+	lookahead []rune
+	head      int
+	// The error the reader failed with, other than io.EOF
+	err error
+	// Position of the next rune
+	pos Position
+}
+
+func NewScanner(reader io.Reader) *Scanner {
+	return &Scanner{
+		reader: bufio.NewReader(reader),
+		pos:    Position{1, 1},
+	}
+}
+
+// Returns the next token, or io.EOF when the end of the source has been reached.
+// An error from the reader is returned instead of the token it interrupted
+func (s *Scanner) Next() (Token, error) {
+	token, err := s.next()
+	if s.err != nil {
+		return Token{}, s.err
+	}
+	return token, err
+}
+
+func (s *Scanner) next() (Token, error) {
+	s.skipWhitespace()
+
+	start := s.pos
+	r, ok := s.peek(0)
+	if !ok {
+		return Token{}, io.EOF
+	}
+
+	switch {
+	case r == '\n':
+		s.advance()
+		return Token{EOS, "\n", start}, nil
+	case r == '/' && s.peekIs(1, '/'):
+		comment := s.readWhile(func(r rune) bool { return r != '\n' })
+		if strings.HasPrefix(comment, "///") && !strings.HasPrefix(comment, "////") {
+			return Token{DocComment, comment, start}, nil
+		}
+		return Token{Comment, comment, start}, nil
+	case r == '/' && s.peekIs(1, '*'):
+		return s.scanBlockComment(start)
+	case r == '"':
+		var sb strings.Builder
+		if err := s.scanString(&sb, start); err != nil {
+			return Token{}, err
+		}
+		return Token{String, sb.String(), start}, nil
+	case r == '`':
+		return s.scanRawString(start)
+	case isDigit(r):
+		return s.scanNumber(start), nil
+	case isIdentStart(r):
+		return s.scanWord(start), nil
+	}
+
+	for _, op := range operators {
+		if s.hasPrefix(op.Value) {
+			for range op.Value {
+				s.advance()
+			}
+			return Token{op.Type, op.Value, start}, nil
+		}
+	}
+
+	return Token{}, &Error{start, fmt.Sprintf("Couldn't find token for `%c`", r)}
+}
+
+// Spaces, tabs and carriage returns separate tokens, newlines are tokens themselves
+func (s *Scanner) skipWhitespace() {
+	for {
+		r, ok := s.peek(0)
+		if !ok || r == '\n' || !unicode.IsSpace(r) {
+			return
+		}
+		s.advance()
 	}
+}
 
-	return tokens, nil
+// Scans `"..."` into `sb`, the scanned value still contains the quotes and
+// escape sequences. Interpolated expressions (`{...}`) can contain strings
+// themselves, so they are scanned up to their closing `}` as a whole
+func (s *Scanner) scanString(sb *strings.Builder, start Position) error {
+	sb.WriteRune(s.advance()) // opening quote
+
+	for {
+		r, ok := s.peek(0)
+		if !ok {
+			return &Error{start, "Unterminated string literal"}
+		}
+
+		if r == '{' {
+			length := s.interpolationLength()
+			if length == -1 {
+				return &Error{s.pos, "Unclosed `{` in string, use `\\{` for a literal curly bracket"}
+			}
+			for i := 0; i < length; i++ {
+				sb.WriteRune(s.advance())
+			}
+			continue
+		}
+
+		sb.WriteRune(s.advance())
+
+		switch r {
+		case '"':
+			return nil
+		case '\\':
+			// The escaped character can never end the string
+			if _, ok := s.peek(0); ok {
+				sb.WriteRune(s.advance())
+			}
+		}
+	}
 }
 
-// Get the next token
-// - `source`: the remaining part of the source that needs to be tokenized
-// - `reg`: the tokens and their regex definitions
-func nextToken(source *string, reg *[]Pair) (*Token, error) {
-	for _, pair := range *reg {
-		re := pair.Regex
-		ty := pair.Type
-		if idx := re.FindStringIndex(*source); len(idx) != 0 {
-			if idx[1] == 0 {
-				continue
+// The amount of runes from the `{` of an interpolation up to and including
+// its closing `}`, or -1 if it isn't closed on the same line. A `"` opens a
+// string inside of the interpolation, so an unclosed `{` followed by the end of
+// the string doesn't take the source after it into the string
+func (s *Scanner) interpolationLength() int {
+	braceLevel := 0
+	inString := false
+	for i := 0; ; i++ {
+		r, ok := s.peek(i)
+		switch {
+		case !ok || r == '\n':
+			return -1
+		case r == '\\':
+			i += 1 // skip escaped character
+		case r == '"':
+			inString = !inString
+		case inString:
+			// not a brace
+		case r == '{':
+			braceLevel += 1
+		case r == '}':
+			braceLevel -= 1
+			if braceLevel == 0 {
+				return i + 1
 			}
-			value := (*source)[idx[0]:idx[1]]
-			*source = strings.Trim((*source)[idx[1]:], " ")
-			return &Token{ty, value}, nil
 		}
 	}
+}
+
+// Scans a raw string (`...`), which can span multiple lines and has no escape
+// sequences
+func (s *Scanner) scanRawString(start Position) (Token, error) {
+	s.advance() // opening backtick
+	var sb strings.Builder
+	sb.WriteRune('`')
+	sb.WriteString(s.readWhile(func(r rune) bool { return r != '`' }))
+	if _, ok := s.peek(0); !ok {
+		return Token{}, &Error{start, "Unterminated raw string literal"}
+	}
+	sb.WriteRune(s.advance()) // closing backtick
+	return Token{String, sb.String(), start}, nil
+}
+
+// Scans a `/* ... */` comment. Block comments can be nested, so commenting out
+// code that already contains a block comment works
+func (s *Scanner) scanBlockComment(start Position) (Token, error) {
+	var sb strings.Builder
+	level := 0
+	for {
+		if s.hasPrefix("/*") {
+			level += 1
+		} else if s.hasPrefix("*/") {
+			level -= 1
+		}
+
+		if s.hasPrefix("/*") || s.hasPrefix("*/") {
+			sb.WriteRune(s.advance())
+			sb.WriteRune(s.advance())
+			if level == 0 {
+				return Token{Comment, sb.String(), start}, nil
+			}
+			continue
+		}
+
+		if _, ok := s.peek(0); !ok {
+			return Token{}, &Error{start, "Unterminated block comment"}
+		}
+		sb.WriteRune(s.advance())
+	}
+}
+
+// Scans an integer or a float. A dot directly followed by a letter is not part
+// of the number, so `6.concat` is an integer followed by a method call
+func (s *Scanner) scanNumber(start Position) Token {
+	value := s.readWhile(isDigit)
+
+	if r, ok := s.peek(0); ok && r == '.' {
+		next, hasNext := s.peek(1)
+		if !hasNext || !isIdentStart(next) {
+			s.advance() // consume .
+			return Token{Float, value + "." + s.readWhile(isDigit), start}
+		}
+	}
+
+	return Token{Integer, value, start}
+}
+
+// Scans an identifier or a keyword. Identifiers may end in `!` or `?` (e.g.
+// `noot!`), as long as it isn't the start of `!=`
+func (s *Scanner) scanWord(start Position) Token {
+	word := s.readWhile(isIdentPart)
+
+	if r, ok := s.peek(0); ok && (r == '!' || r == '?') && !s.peekIs(1, '=') {
+		word += string(s.advance())
+	}
+
+	if ty, isKeyword := keywords[word]; isKeyword {
+		return Token{ty, word, start}
+	}
+	return Token{Ident, word, start}
+}
+
+// Consume runes as long as `pred` holds for them
+func (s *Scanner) readWhile(pred func(rune) bool) string {
+	var sb strings.Builder
+	for {
+		r, ok := s.peek(0)
+		if !ok || !pred(r) {
+			return sb.String()
+		}
+		sb.WriteRune(s.advance())
+	}
+}
+
+// Look at the rune `n` positions ahead without consuming it. Returns false at
+// the end of the source, or when the reader failed (see `s.err`)
+func (s *Scanner) peek(n int) (rune, bool) {
+	for len(s.lookahead)-s.head <= n {
+		if s.err != nil {
+			return 0, false
+		}
+		r, _, err := s.reader.ReadRune()
+		if err != nil {
+			if err != io.EOF {
+				s.err = err
+			}
+			return 0, false
+		}
+		if len(s.lookahead) == cap(s.lookahead) && s.head > 0 {
+			// Move the runes that haven't been consumed to the front of the
+			// buffer, instead of growing it
+			s.lookahead = s.lookahead[:copy(s.lookahead, s.lookahead[s.head:])]
+			s.head = 0
+		}
+		s.lookahead = append(s.lookahead, r)
+	}
+	return s.lookahead[s.head+n], true
+}
+
+func (s *Scanner) peekIs(n int, expected rune) bool {
+	r, ok := s.peek(n)
+	return ok && r == expected
+}
+
+func (s *Scanner) hasPrefix(prefix string) bool {
+	i := 0
+	for _, expected := range prefix {
+		if !s.peekIs(i, expected) {
+			return false
+		}
+		i++
+	}
+	return true
+}
+
+// Consume the next rune. Should only be called after a successful peek
+func (s *Scanner) advance() rune {
+	r := s.lookahead[s.head]
+	s.head++
+	if s.head == len(s.lookahead) {
+		s.lookahead = s.lookahead[:0]
+		s.head = 0
+	}
+	if r == '\n' {
+		s.pos.Line++
+		s.pos.Column = 1
+	} else {
+		s.pos.Column++
+	}
+	return r
+}
+
+func isDigit(r rune) bool {
+	return r >= '0' && r <= '9'
+}
+
+func isIdentStart(r rune) bool {
+	return r == '_' || unicode.IsLetter(r)
+}
 
-	return nil, errors.New(fmt.Sprintf("Couldn't find token for `%s`", *source))
+func isIdentPart(r rune) bool {
+	return isIdentStart(r) || unicode.IsDigit(r)
 }
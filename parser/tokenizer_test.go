@@ -1,15 +1,20 @@
 package parser
 
 import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
 	"testing"
+	"testing/iotest"
 )
 
 func TestAssignment(t *testing.T) {
 	source := "a := 5"
 	expected := []Token{
-		{Ident, "a"},
-		{Declare, ":="},
-		{Integer, "5"},
+		{Type: Ident, Value: "a"},
+		{Type: Declare, Value: ":="},
+		{Type: Integer, Value: "5"},
 	}
 
 	testTokenizing(source, expected, t)
@@ -18,11 +23,11 @@ func TestAssignment(t *testing.T) {
 func TestNoot(t *testing.T) {
 	source := "noot!(6);"
 	expected := []Token{
-		{Ident, "noot!"},
-		{OpenPar, "("},
-		{Integer, "6"},
-		{ClosedPar, ")"},
-		{EOS, ";"},
+		{Type: Ident, Value: "noot!"},
+		{Type: OpenPar, Value: "("},
+		{Type: Integer, Value: "6"},
+		{Type: ClosedPar, Value: ")"},
+		{Type: EOS, Value: ";"},
 	}
 
 	testTokenizing(source, expected, t)
@@ -31,23 +36,23 @@ func TestNoot(t *testing.T) {
 func TestOperators(t *testing.T) {
 	source := "*+-/ 6 + 4 == != || && !true > < >= <="
 	expected := []Token{
-		{Star, "*"},
-		{Plus, "+"},
-		{Minus, "-"},
-		{Slash, "/"},
-		{Integer, "6"},
-		{Plus, "+"},
-		{Integer, "4"},
-		{DEqual, "=="},
-		{DNEqual, "!="},
-		{Or, "||"},
-		{And, "&&"},
-		{Not, "!"},
-		{Bool, "true"},
-		{GT, ">"},
-		{LT, "<"},
-		{GTE, ">="},
-		{LTE, "<="},
+		{Type: Star, Value: "*"},
+		{Type: Plus, Value: "+"},
+		{Type: Minus, Value: "-"},
+		{Type: Slash, Value: "/"},
+		{Type: Integer, Value: "6"},
+		{Type: Plus, Value: "+"},
+		{Type: Integer, Value: "4"},
+		{Type: DEqual, Value: "=="},
+		{Type: DNEqual, Value: "!="},
+		{Type: Or, Value: "||"},
+		{Type: And, Value: "&&"},
+		{Type: Not, Value: "!"},
+		{Type: Bool, Value: "true"},
+		{Type: GT, Value: ">"},
+		{Type: LT, Value: "<"},
+		{Type: GTE, Value: ">="},
+		{Type: LTE, Value: "<="},
 	}
 
 	testTokenizing(source, expected, t)
@@ -56,17 +61,17 @@ func TestOperators(t *testing.T) {
 func TestFunction(t *testing.T) {
 	source := "def f(arg1, arg2) { return arg1 }"
 	expected := []Token{
-		{Def, "def"},
-		{Ident, "f"},
-		{OpenPar, "("},
-		{Ident, "arg1"},
-		{Comma, ","},
-		{Ident, "arg2"},
-		{ClosedPar, ")"},
-		{OpenCurlPar, "{"},
-		{Return, "return"},
-		{Ident, "arg1"},
-		{ClosedCurlPar, "}"},
+		{Type: Def, Value: "def"},
+		{Type: Ident, Value: "f"},
+		{Type: OpenPar, Value: "("},
+		{Type: Ident, Value: "arg1"},
+		{Type: Comma, Value: ","},
+		{Type: Ident, Value: "arg2"},
+		{Type: ClosedPar, Value: ")"},
+		{Type: OpenCurlPar, Value: "{"},
+		{Type: Return, Value: "return"},
+		{Type: Ident, Value: "arg1"},
+		{Type: ClosedCurlPar, Value: "}"},
 	}
 
 	testTokenizing(source, expected, t)
@@ -74,65 +79,257 @@ func TestFunction(t *testing.T) {
 
 func TestNil(t *testing.T) {
 	source := "nil"
-	expected := []Token{{Nil, "nil"}}
+	expected := []Token{{Type: Nil, Value: "nil"}}
 
 	testTokenizing(source, expected, t)
 }
 
 func TestStringToken(t *testing.T) {
 	source := "\"Hello \\\" World\""
-	expected := []Token{{String, source}}
+	expected := []Token{{Type: String, Value: source}}
 
 	testTokenizing(source, expected, t)
 }
 
+func TestRawStringToken(t *testing.T) {
+	source := "`multi\n\"line\" \\n`"
+	expected := []Token{{Type: String, Value: source}}
+
+	testTokenizing(source, expected, t)
+}
+
+func TestInterpolatedStringToken(t *testing.T) {
+	source := `"a {b.concat("}")} c" d`
+	expected := []Token{{Type: String, Value: `"a {b.concat("}")} c"`}, {Type: Ident, Value: "d"}}
+
+	testTokenizing(source, expected, t)
+}
+
+func TestRepetitionInStringToken(t *testing.T) {
+	source := `regex("a{3}")`
+	expected := []Token{{Type: Ident, Value: "regex"}, {Type: OpenPar, Value: "("}, {Type: String, Value: `"a{3}"`}, {Type: ClosedPar, Value: ")"}}
+
+	testTokenizing(source, expected, t)
+}
+
+// An interpolation ends with the line, so an unclosed `{` can't take the
+// source after the string into it
+func TestUnclosedInterpolation(t *testing.T) {
+	for source, pos := range map[string]string{
+		"noot!(\"price: {\")\nnoot!(\"} ok\")": "1:15",
+		`noot!("{")`:                           "1:8",
+		"a := \"x {\ny}\"":                     "1:9",
+	} {
+		_, err := Tokenize(source)
+		expected := pos + ": Unclosed `{` in string, use `\\{` for a literal curly bracket"
+		if err == nil || err.Error() != expected {
+			t.Errorf("Got %v for %q, but expected %s", err, source, expected)
+		}
+	}
+}
+
 func TestFloatToken(t *testing.T) {
 	source := "1. 4.56"
-	expected := []Token{{Float, "1."}, {Float, "4.56"}}
+	expected := []Token{{Type: Float, Value: "1."}, {Type: Float, Value: "4.56"}}
 
 	testTokenizing(source, expected, t)
 }
 
 func TestBoolToken(t *testing.T) {
 	source := "true false"
-	expected := []Token{{Bool, "true"}, {Bool, "false"}}
+	expected := []Token{{Type: Bool, Value: "true"}, {Type: Bool, Value: "false"}}
 	testTokenizing(source, expected, t)
 }
 
 func TestIfElseToken(t *testing.T) {
 	source := "if true { } elsif { } else {}"
 	expected := []Token{
-		{If, "if"}, {Bool, "true"}, {OpenCurlPar, "{"}, {ClosedCurlPar, "}"},
-		{Elsif, "elsif"}, {OpenCurlPar, "{"}, {ClosedCurlPar, "}"},
-		{Else, "else"}, {OpenCurlPar, "{"}, {ClosedCurlPar, "}"},
+		{Type: If, Value: "if"}, {Type: Bool, Value: "true"}, {Type: OpenCurlPar, Value: "{"}, {Type: ClosedCurlPar, Value: "}"},
+		{Type: Elsif, Value: "elsif"}, {Type: OpenCurlPar, Value: "{"}, {Type: ClosedCurlPar, Value: "}"},
+		{Type: Else, Value: "else"}, {Type: OpenCurlPar, Value: "{"}, {Type: ClosedCurlPar, Value: "}"},
 	}
 	testTokenizing(source, expected, t)
 }
 
 func TestLoopingTokens(t *testing.T) {
 	source := "while"
-	expected := []Token{{While, "while"}}
+	expected := []Token{{Type: While, Value: "while"}}
+	testTokenizing(source, expected, t)
+}
+
+func TestInterfaceTokens(t *testing.T) {
+	source := "interface x is as island"
+	expected := []Token{{Type: Interface, Value: "interface"}, {Type: Ident, Value: "x"}, {Type: Is, Value: "is"}, {Type: As, Value: "as"}, {Type: Ident, Value: "island"}}
 	testTokenizing(source, expected, t)
 }
 
 func TestSquareBracket(t *testing.T) {
 	source := "[]"
-	expected := []Token{{OpenSquarePar, "["}, {ClosedSquarePar, "]"}}
+	expected := []Token{{Type: OpenSquarePar, Value: "["}, {Type: ClosedSquarePar, Value: "]"}}
 	testTokenizing(source, expected, t)
 }
 
 func TestAssignmentOperatorsTokens(t *testing.T) {
 	source := "+= -= *= /="
-	expected := []Token{{PlusEqual, "+="}, {MinEqual, "-="}, {StarEqual, "*="}, {SlashEqual, "/="}}
+	expected := []Token{{Type: PlusEqual, Value: "+="}, {Type: MinEqual, Value: "-="}, {Type: StarEqual, Value: "*="}, {Type: SlashEqual, Value: "/="}}
 	testTokenizing(source, expected, t)
 }
 
 func TestDotToken(t *testing.T) {
 	source := "."
-	expected := []Token{{Dot, "."}}
+	expected := []Token{{Type: Dot, Value: "."}}
 	testTokenizing(source, expected, t)
 }
 
+func TestKeywordPrefixes(t *testing.T) {
+	source := "define iffy nilly returned whiled trueish elsewhere"
+	expected := []Token{
+		{Type: Ident, Value: "define"},
+		{Type: Ident, Value: "iffy"},
+		{Type: Ident, Value: "nilly"},
+		{Type: Ident, Value: "returned"},
+		{Type: Ident, Value: "whiled"},
+		{Type: Ident, Value: "trueish"},
+		{Type: Ident, Value: "elsewhere"},
+	}
+	testTokenizing(source, expected, t)
+}
+
+func TestIdentNotEqual(t *testing.T) {
+	source := "a!=b is_ok? noot!(a)"
+	expected := []Token{
+		{Type: Ident, Value: "a"},
+		{Type: DNEqual, Value: "!="},
+		{Type: Ident, Value: "b"},
+		{Type: Ident, Value: "is_ok?"},
+		{Type: Ident, Value: "noot!"},
+		{Type: OpenPar, Value: "("},
+		{Type: Ident, Value: "a"},
+		{Type: ClosedPar, Value: ")"},
+	}
+	testTokenizing(source, expected, t)
+}
+
+func TestMethodOnIntToken(t *testing.T) {
+	source := "6.concat(9)"
+	expected := []Token{
+		{Type: Integer, Value: "6"},
+		{Type: Dot, Value: "."},
+		{Type: Ident, Value: "concat"},
+		{Type: OpenPar, Value: "("},
+		{Type: Integer, Value: "9"},
+		{Type: ClosedPar, Value: ")"},
+	}
+	testTokenizing(source, expected, t)
+}
+
+func TestCommentToken(t *testing.T) {
+	source := "a := 1 // comment\nb"
+	expected := []Token{
+		{Type: Ident, Value: "a"},
+		{Type: Declare, Value: ":="},
+		{Type: Integer, Value: "1"},
+		{Type: Comment, Value: "// comment"},
+		{Type: EOS, Value: "\n"},
+		{Type: Ident, Value: "b"},
+	}
+	testTokenizing(source, expected, t)
+}
+
+func TestBlockCommentToken(t *testing.T) {
+	source := "a /* outer /* inner */ still\ncomment */ b /// doc\n//// not doc"
+	expected := []Token{
+		{Type: Ident, Value: "a"},
+		{Type: Comment, Value: "/* outer /* inner */ still\ncomment */"},
+		{Type: Ident, Value: "b"},
+		{Type: DocComment, Value: "/// doc"},
+		{Type: EOS, Value: "\n"},
+		{Type: Comment, Value: "//// not doc"},
+	}
+	testTokenizing(source, expected, t)
+}
+
+func TestUnterminatedBlockComment(t *testing.T) {
+	_, err := Tokenize("a /* /* */")
+	if err == nil {
+		t.Fatal("Expected an error for an unterminated block comment")
+	}
+}
+
+func TestTokenPositions(t *testing.T) {
+	tokens, err := Tokenize("a := 1\n\tnoot!(\"é\", a)")
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	expected := []Position{{1, 1}, {1, 3}, {1, 6}, {1, 7}, {2, 2}, {2, 7}, {2, 8}, {2, 11}, {2, 13}, {2, 14}}
+	if len(tokens) != len(expected) {
+		t.Fatalf("Expected %d tokens, but got %#v\n", len(expected), tokens)
+	}
+	for i, pos := range expected {
+		if tokens[i].Pos != pos {
+			t.Fatalf("Expected %v to be at %v, but got %v\n", tokens[i].Value, pos, tokens[i].Pos)
+		}
+	}
+}
+
+func TestInvalidToken(t *testing.T) {
+	_, err := Tokenize("a := 1\nb := @")
+	if err == nil || !strings.HasPrefix(err.Error(), "2:6:") {
+		t.Fatalf("Expected an error at 2:6, but got %v", err)
+	}
+}
+
+func TestUnterminatedString(t *testing.T) {
+	_, err := Tokenize(`a := "abc`)
+	if err == nil {
+		t.Fatal("Expected an error for an unterminated string")
+	}
+}
+
+func TestScannerStreaming(t *testing.T) {
+	scanner := NewScanner(strings.NewReader("while i < 10 {"))
+	var types []TT
+	for {
+		token, err := scanner.Next()
+		if err != nil {
+			break
+		}
+		types = append(types, token.Type)
+	}
+
+	expected := []TT{While, Ident, LT, Integer, OpenCurlPar}
+	if fmt.Sprint(types) != fmt.Sprint(expected) {
+		t.Fatalf("Expected %v, but got %v", expected, types)
+	}
+}
+
+func TestReaderError(t *testing.T) {
+	readErr := errors.New("disk on fire")
+	reader := io.MultiReader(strings.NewReader("a := \"abc"), iotest.ErrReader(readErr))
+	_, err := TokenizeReader(reader)
+	if err != readErr {
+		t.Fatalf("Expected the error of the reader, but got %v", err)
+	}
+
+	_, err = TokenizeReader(io.MultiReader(strings.NewReader("a := 1\n"), iotest.ErrReader(readErr)))
+	if err != readErr {
+		t.Fatalf("Expected the error of the reader, but got %v", err)
+	}
+}
+
+func TestLongLookahead(t *testing.T) {
+	comment := "/*" + strings.Repeat("* / ", 10000) + "*/"
+	source := comment + " a := \"" + strings.Repeat("{\"x\"}", 1000) + "\""
+	tokens, err := Tokenize(source)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if len(tokens) != 4 || tokens[0].Value != comment || tokens[2].Pos != (Position{1, len(comment) + 4}) {
+		t.Fatalf("Got %d tokens, starting with %.20q", len(tokens), tokens[0].Value)
+	}
+}
+
 func testTokenizing(source string, expected []Token, t *testing.T) {
 	tokens, err := Tokenize(source)
 
@@ -145,8 +342,29 @@ func testTokenizing(source string, expected []Token, t *testing.T) {
 	}
 
 	for i, token := range expected {
-		if token != tokens[i] {
+		if !sameToken(token, tokens[i]) {
 			t.Fatalf("Expected %#v, but got %#v\n", token, tokens[i])
 		}
 	}
 }
+
+// Tokens are compared without their position
+func sameToken(a, b Token) bool {
+	return a.Type == b.Type && a.Value == b.Value
+}
+
+// The scanner should scale linearly with the size of the source
+func BenchmarkTokenize(b *testing.B) {
+	statement := `def add(a, b) { return a + b }; noot!(add(1, 2.5), "hello \" world") // comment` + "\n"
+	for _, size := range []int{64 << 10, 1 << 20, 4 << 20} {
+		source := strings.Repeat(statement, size/len(statement))
+		b.Run(fmt.Sprintf("%dKiB", size>>10), func(b *testing.B) {
+			b.SetBytes(int64(len(source)))
+			for i := 0; i < b.N; i++ {
+				if _, err := Tokenize(source); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
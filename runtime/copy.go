@@ -0,0 +1,104 @@
+package runtime
+
+import "reflect"
+
+// Copies arrays, tuples and maps, including the ones inside of them. Other
+// values are returned as they are. The interpreter copies the values read from
+// constants, so they can't be changed through another variable
+func Copy(value interface{}) interface{} {
+	return copyValue(value, map[valueKey]interface{}{})
+}
+
+// Identifies an array or map by where its elements are stored. `length` is -1
+// for maps
+type valueKey struct {
+	pointer uintptr
+	length  int
+}
+
+// Whether an array, tuple or map contains itself, directly or through the
+// values inside of it. Such values can't be written out, e.g. as JSON
+func ContainsItself(value interface{}) bool {
+	return containsItself(value, map[valueKey]bool{})
+}
+
+// `visiting` holds the values that contain the current one
+func containsItself(value interface{}, visiting map[valueKey]bool) bool {
+	var key valueKey
+	var elements []interface{}
+	switch value.(type) {
+	case []interface{}:
+		elements = value.([]interface{})
+	case Tuple:
+		elements = value.(Tuple)
+	case map[string]interface{}:
+		m := value.(map[string]interface{})
+		key = valueKey{reflect.ValueOf(m).Pointer(), -1}
+		for _, v := range m {
+			elements = append(elements, v)
+		}
+	default:
+		return false
+	}
+	if len(elements) == 0 {
+		return false
+	}
+	if key.length == 0 {
+		key = valueKey{reflect.ValueOf(elements).Pointer(), len(elements)}
+	}
+
+	if visiting[key] {
+		return true
+	}
+	visiting[key] = true
+	defer delete(visiting, key)
+	for _, element := range elements {
+		if containsItself(element, visiting) {
+			return true
+		}
+	}
+	return false
+}
+
+// `copying` holds the copies of the values that contain the current one, so a
+// value that contains itself is copied into a value that contains itself
+func copyValue(value interface{}, copying map[valueKey]interface{}) interface{} {
+	switch value.(type) {
+	case []interface{}:
+		return copyElements(value.([]interface{}), copying)
+	case Tuple:
+		return Tuple(copyElements(value.(Tuple), copying))
+	case map[string]interface{}:
+		m := value.(map[string]interface{})
+		key := valueKey{reflect.ValueOf(m).Pointer(), -1}
+		if copied, isCopying := copying[key]; isCopying {
+			return copied
+		}
+		copied := make(map[string]interface{}, len(m))
+		copying[key] = copied
+		defer delete(copying, key)
+		for k, v := range m {
+			copied[k] = copyValue(v, copying)
+		}
+		return copied
+	default:
+		return value
+	}
+}
+
+func copyElements(elements []interface{}, copying map[valueKey]interface{}) []interface{} {
+	if len(elements) == 0 {
+		return []interface{}{}
+	}
+	key := valueKey{reflect.ValueOf(elements).Pointer(), len(elements)}
+	if copied, isCopying := copying[key]; isCopying {
+		return copied.([]interface{})
+	}
+	copied := make([]interface{}, len(elements))
+	copying[key] = copied
+	defer delete(copying, key)
+	for i, element := range elements {
+		copied[i] = copyValue(element, copying)
+	}
+	return copied
+}
@@ -0,0 +1,72 @@
+package runtime
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// The file system libraries read and write through. Reading goes through the
+// `fs.FS` functions (`fs.ReadFile`, `fs.ReadDir`, `fs.Stat`, `fs.Glob`), so an
+// implementation can be as small as an `fs.FS` with the writing methods below.
+// Hosts replace the runtime's file system to sandbox scripts, or with an
+// in-memory one in tests
+type FileSystem interface {
+	fs.FS
+	// Creates or truncates the file
+	WriteFile(name string, data []byte) error
+	// Creates the file if it doesn't exist
+	AppendFile(name string, data []byte) error
+	// Creates the directory and any missing parents
+	MkdirAll(name string) error
+	// Removes a file or an empty directory
+	Remove(name string) error
+}
+
+// The operating system's file system. Unlike `os.DirFS`, paths are OS paths:
+// they can be absolute or relative to the working directory
+type OSFileSystem struct{}
+
+func (OSFileSystem) Open(name string) (fs.File, error) {
+	return os.Open(name)
+}
+
+func (OSFileSystem) ReadFile(name string) ([]byte, error) {
+	return os.ReadFile(name)
+}
+
+func (OSFileSystem) ReadDir(name string) ([]fs.DirEntry, error) {
+	return os.ReadDir(name)
+}
+
+func (OSFileSystem) Stat(name string) (fs.FileInfo, error) {
+	return os.Stat(name)
+}
+
+func (OSFileSystem) Glob(pattern string) ([]string, error) {
+	return filepath.Glob(pattern)
+}
+
+func (OSFileSystem) WriteFile(name string, data []byte) error {
+	return os.WriteFile(name, data, 0644)
+}
+
+func (OSFileSystem) AppendFile(name string, data []byte) error {
+	file, err := os.OpenFile(name, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := file.Write(data); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+func (OSFileSystem) MkdirAll(name string) error {
+	return os.MkdirAll(name, 0755)
+}
+
+func (OSFileSystem) Remove(name string) error {
+	return os.Remove(name)
+}
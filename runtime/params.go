@@ -0,0 +1,136 @@
+package runtime
+
+import (
+	"errors"
+	"fmt"
+)
+
+// A parameter of a function, used to check the arguments of a call
+type Param struct {
+	Name string
+	// Whether the argument can be left out (it has a default value)
+	Optional bool
+	// Whether the parameter takes the remaining positional arguments as an
+	// array (`...rest`). Only the last parameter can be variadic
+	Variadic bool
+}
+
+// A native function whose arguments are checked against `params` before `fn`
+// is called. `fn` gets an argument for every parameter that isn't variadic, in
+// order (nil for optional arguments that weren't given), followed by the
+// values of the variadic parameter. Named arguments are matched to the
+// parameters by name
+func NewFunctionWithParams(name string, params []Param, fn NativeFunction) *Function {
+	return &Function{Name: name, Arity: arity(params), Params: params, Call: func(r *Runtime, args []interface{}) (interface{}, error) {
+		values, _, err := BindArgs(name, params, args)
+		if err != nil {
+			return nil, err
+		}
+
+		var flat []interface{}
+		for i, param := range params {
+			if param.Variadic {
+				flat = append(flat, values[i].([]interface{})...)
+			} else {
+				flat = append(flat, values[i])
+			}
+		}
+		return fn(r, flat)
+	}}
+}
+
+// Matches the arguments of a call to `name` with its parameters: positional
+// arguments in order, then named arguments by name. Returns the value of every
+// parameter and whether it was given. The positional arguments left over are
+// collected in an array for the variadic parameter, which is always given
+func BindArgs(name string, params []Param, args []interface{}) (values []interface{}, given []bool, err error) {
+	positional, named := SplitNamedArgs(args)
+	values = make([]interface{}, len(params))
+	given = make([]bool, len(params))
+
+	fixed := arity(params)
+	if len(positional) > fixed && fixed == len(params) {
+		return nil, nil, arityError(name, params, len(positional))
+	}
+	for i := 0; i < fixed && i < len(positional); i++ {
+		values[i], given[i] = positional[i], true
+	}
+	if fixed != len(params) {
+		rest := []interface{}{}
+		if len(positional) > fixed {
+			rest = append(rest, positional[fixed:]...)
+		}
+		values[fixed], given[fixed] = rest, true
+	}
+
+	for argName, value := range named {
+		index := paramIndex(params, argName)
+		if index == -1 {
+			return nil, nil, errors.New(fmt.Sprintf("`%s` has no argument `%s`", name, argName))
+		}
+		if params[index].Variadic {
+			return nil, nil, errors.New(fmt.Sprintf("`%s` takes `...%s` as positional arguments, it can't be given by name", name, argName))
+		}
+		if given[index] {
+			return nil, nil, errors.New(fmt.Sprintf("`%s` got the argument `%s` twice", name, argName))
+		}
+		values[index], given[index] = value, true
+	}
+
+	for i, param := range params {
+		if given[i] || param.Optional {
+			continue
+		}
+		if len(named) == 0 {
+			return nil, nil, arityError(name, params, len(positional))
+		}
+		return nil, nil, errors.New(fmt.Sprintf("`%s` is missing the argument `%s`", name, param.Name))
+	}
+
+	return values, given, nil
+}
+
+func paramIndex(params []Param, name string) int {
+	for i, param := range params {
+		if param.Name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// The amount of arguments that aren't variadic
+func arity(params []Param) int {
+	if len(params) != 0 && params[len(params)-1].Variadic {
+		return len(params) - 1
+	}
+	return len(params)
+}
+
+// E.g. "`greet` expects 1 to 2 arguments, but got 3"
+func arityError(name string, params []Param, got int) error {
+	required := 0
+	for _, param := range params {
+		if !param.Optional && !param.Variadic {
+			required += 1
+		}
+	}
+	max := arity(params)
+
+	var expected string
+	switch {
+	case max != len(params) && required == 1:
+		expected = "at least 1 argument"
+	case max != len(params):
+		expected = fmt.Sprintf("at least %d arguments", required)
+	case required == max && max == 0:
+		expected = "no arguments"
+	case required == max && max == 1:
+		expected = "1 argument"
+	case required == max:
+		expected = fmt.Sprintf("%d arguments", max)
+	default:
+		expected = fmt.Sprintf("%d to %d arguments", required, max)
+	}
+	return errors.New(fmt.Sprintf("`%s` expects %s, but got %d", name, expected, got))
+}
@@ -2,41 +2,205 @@
 package runtime
 
 import (
+	"bufio"
+	"context"
 	"errors"
 	"fmt"
 	"io"
+	"math/rand"
 	"reflect"
+	"time"
 )
 
 type NativeFunction = func(*Runtime, []interface{}) (interface{}, error)
 
-// TODO: scopes for if and loops, etc.
+// A function value. Functions declared in noot and native functions registered
+// by libraries are both stored as a Function
+type Function struct {
+	Name string
+	// The amount of arguments the function takes, not counting a `...rest`
+	// argument, or -1 if it is not known (e.g. for native functions)
+	Arity int
+	// The parameters the arguments of a call are checked against, nil if they
+	// aren't known
+	Params []Param
+	// Whether a native function without `Params` is passed named arguments.
+	// Calling other functions without `Params` with named arguments is an error
+	TakesNamedArgs bool
+	// Documentation taken from the `///` comments above the declaration
+	Doc  string
+	Call NativeFunction
+}
+
+func NewFunction(name string, fn NativeFunction) *Function {
+	return &Function{Name: name, Arity: -1, Call: fn}
+}
+
+// A native function that handles named arguments itself, they are passed as a
+// `NamedArgs` map in the last argument
+func NewFunctionWithNamedArgs(name string, fn NativeFunction) *Function {
+	return &Function{Name: name, Arity: -1, TakesNamedArgs: true, Call: fn}
+}
+
+// The named arguments of a call (`f(a, name = b)`). They are passed to native
+// functions created with `NewFunctionWithNamedArgs` as the last argument
+type NamedArgs map[string]interface{}
+
+// Splits the named arguments off the arguments of a native function. `named`
+// is empty (but not nil) when the call has none
+func SplitNamedArgs(args []interface{}) (positional []interface{}, named NamedArgs) {
+	if len(args) != 0 {
+		if named, ok := args[len(args)-1].(NamedArgs); ok {
+			return args[:len(args)-1], named
+		}
+	}
+	return args, NamedArgs{}
+}
+
+// An immutable, fixed size list of values, e.g. `(1, "a")`. Functions return
+// multiple values as a tuple
+type Tuple []interface{}
+
+// Returned by a method that changes the value it was called on (e.g.
+// `array.push`). When the method was called on a variable, the interpreter
+// stores `Receiver` in that variable. `Result` is the value of the call
+type MutatedReceiver struct {
+	Receiver interface{}
+	Result   interface{}
+}
+
+// A named collection of values and functions (e.g. `math`). Members are read
+// with `module.name` and called with `module.name(args)`
+type Module struct {
+	Name    string
+	Members map[string]interface{}
+}
+
+func NewModule(name string) *Module {
+	return &Module{Name: name, Members: make(map[string]interface{})}
+}
+
+// Functions are stored as `*Function`
+func (module *Module) SetFunc(name string, fn NativeFunction) {
+	module.Members[name] = NewFunction(module.Name+"."+name, fn)
+}
+
+// Like `SetFunc`, but the arguments are checked against `params`, see
+// `NewFunctionWithParams`
+func (module *Module) SetFuncWithParams(name string, params []Param, fn NativeFunction) {
+	module.Members[name] = NewFunctionWithParams(module.Name+"."+name, params, fn)
+}
+
+func (module *Module) String() string {
+	return "module " + module.Name
+}
+
+// A set of methods, declared with `interface Name { method(args) }`. A value
+// implements an interface when it has all of its methods, e.g. a string
+// implements `interface Sized { len() }` through the `len` method registered
+// for strings
+type Interface struct {
+	Name    string
+	Methods []string
+	Doc     string
+}
+
+func (iface *Interface) String() string {
+	return "interface " + iface.Name
+}
+
+// The time as seen by libraries. Hosts can replace the runtime's clock, e.g. to
+// freeze time in tests
+type Clock interface {
+	Now() time.Time
+	// Waits for the duration, or until the context is cancelled
+	Sleep(ctx context.Context, duration time.Duration) error
+}
+
+// The real time
+type SystemClock struct{}
+
+func (SystemClock) Now() time.Time {
+	return time.Now()
+}
+
+func (SystemClock) Sleep(ctx context.Context, duration time.Duration) error {
+	timer := time.NewTimer(duration)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
 // TODO: allow a[1][1] ...
 type Runtime struct {
+	// The scopes from the outermost to the current one. "LIBRARY" holds the
+	// modules of libraries and encloses "GLOBAL", so a script can declare a
+	// variable named after a module, which shadows the module
 	Scopes []string
 	// Scope names => Variable names => values
-	Vars           map[string]map[string]interface{}
-	Funcs          map[string]map[string]func(*Runtime, []interface{}) (interface{}, error)
+	Vars map[string]map[string]interface{}
+	// Scope names => names of the variables that can't be assigned
+	Consts         map[string]map[string]bool
+	Funcs          map[string]map[string]*Function
 	Methods        map[reflect.Type]map[string]func(*Runtime, []interface{}) (interface{}, error)
 	Stdout, Stderr io.Writer
 	Stdin          io.Reader
+	// Source of random numbers for libraries. Seeded with the current time, the
+	// host can replace it with a fixed seed for reproducible runs
+	Rand  *rand.Rand
+	Clock Clock
+	// The files scripts can read and write, the OS's file system by default
+	FS FileSystem
+	// Cancelling the context stops the script at the next loop iteration or
+	// function call, and interrupts `sleep`
+	Context context.Context
+	// Writes a warning to `Stderr` when a declaration shadows a variable of an
+	// enclosing scope. Off by default
+	WarnShadowing bool
+
+	// Buffers `Stdin` for `StdinReader`
+	stdinReader *bufio.Reader
+	stdinSource io.Reader
 }
 
 func NewRuntime(stdout, stderr io.Writer, stdin io.Reader) Runtime {
 	runtime := Runtime{
 		Vars:    make(map[string]map[string]interface{}),
-		Funcs:   make(map[string]map[string]func(*Runtime, []interface{}) (interface{}, error)),
+		Consts:  make(map[string]map[string]bool),
+		Funcs:   make(map[string]map[string]*Function),
 		Methods: make(map[reflect.Type]map[string]NativeFunction),
 		Stdout:  stdout,
 		Stderr:  stderr,
 		Stdin:   stdin,
+		Rand:    rand.New(rand.NewSource(time.Now().UnixNano())),
+		Clock:   SystemClock{},
+		FS:      OSFileSystem{},
+		Context: context.Background(),
+	}
+	for _, scope := range []string{"LIBRARY", "GLOBAL"} {
+		runtime.Vars[scope] = make(map[string]interface{})
+		runtime.Consts[scope] = make(map[string]bool)
+		runtime.Funcs[scope] = make(map[string]*Function)
 	}
-	runtime.Vars["GLOBAL"] = make(map[string]interface{})
-	runtime.Funcs["GLOBAL"] = make(map[string]func(*Runtime, []interface{}) (interface{}, error))
-	runtime.Scopes = []string{"GLOBAL"}
+	runtime.Scopes = []string{"LIBRARY", "GLOBAL"}
 	return runtime
 }
 
+// A buffered reader of `Stdin`. Libraries that read input share it, so input
+// buffered by one of them isn't lost to the others. A new reader is made when
+// the host replaces `Stdin`
+func (runtime *Runtime) StdinReader() *bufio.Reader {
+	if runtime.stdinReader == nil || runtime.stdinSource != runtime.Stdin {
+		runtime.stdinReader = bufio.NewReader(runtime.Stdin)
+		runtime.stdinSource = runtime.Stdin
+	}
+	return runtime.stdinReader
+}
+
 func (runtime *Runtime) GetVar(varname string) (interface{}, error) {
 	for i := len(runtime.Scopes) - 1; i >= 0; i-- {
 		scope := runtime.Scopes[i]
@@ -55,17 +219,37 @@ func (runtime *Runtime) GetVar(varname string) (interface{}, error) {
 }
 
 func (runtime *Runtime) SetVar(scopename string, varname string, varval interface{}) {
-	runtime.Vars[runtime.Scopes[len(runtime.Scopes)-1]][varname] = varval
+	runtime.Vars[scopename][varname] = varval
 }
 
-func (runtime *Runtime) SetArrayIndex(varname string, index int64, varval interface{}) {
-	for i := len(runtime.Scopes) - 1; i >= 0; i-- {
-		scope := runtime.Scopes[i]
-		_, ok := runtime.Vars[scope][varname]
-		if ok {
-			runtime.Vars[scope][varname].([]interface{})[index] = varval
-		}
+// Declares a constant, a variable that scripts can't assign. Hosts use it for
+// values that scripts shouldn't clobber, e.g. modules or configuration
+func (runtime *Runtime) SetConst(scopename string, name string, value interface{}) {
+	runtime.Vars[scopename][name] = value
+	runtime.Consts[scopename][name] = true
+}
+
+// Whether the variable with this name is a constant
+func (runtime *Runtime) IsConst(varname string) bool {
+	exists, scope := runtime.VarExists(varname)
+	return exists && runtime.Consts[scope][varname]
+}
+
+// Assigns an element of the array in the closest variable with this name
+func (runtime *Runtime) SetArrayIndex(varname string, index int64, varval interface{}) error {
+	exists, scope := runtime.VarExists(varname)
+	if !exists {
+		return errors.New(fmt.Sprintf("Variable %s is not declared", varname))
 	}
+	array, isArray := runtime.Vars[scope][varname].([]interface{})
+	if !isArray {
+		return errors.New(fmt.Sprintf("Cannot assign to an element of `%s`, it isn't an array", varname))
+	}
+	if index < 0 || index >= int64(len(array)) {
+		return errors.New(fmt.Sprintf("Index %d is out of range, the length is %d", index, len(array)))
+	}
+	array[index] = varval
+	return nil
 }
 
 func (runtime *Runtime) ApplyToVariable(scopename string, varname string, operation func(interface{}) (interface{}, error)) error {
@@ -87,7 +271,14 @@ func (runtime *Runtime) VarExists(varname string) (bool, string) {
 	return false, ""
 }
 
-func (runtime *Runtime) GetFunc(funcname string) NativeFunction {
+// Whether the variable is declared in the current scope. A declaration only
+// conflicts with these, it shadows the variables of the enclosing scopes
+func (runtime *Runtime) VarExistsInCurrentScope(varname string) bool {
+	_, exists := runtime.Vars[runtime.CurrentScope()][varname]
+	return exists
+}
+
+func (runtime *Runtime) GetFunc(funcname string) *Function {
 	for i := len(runtime.Scopes) - 1; i >= 0; i-- {
 		scope := runtime.Scopes[i]
 		val, ok := runtime.Funcs[scope][funcname]
@@ -99,8 +290,9 @@ func (runtime *Runtime) GetFunc(funcname string) NativeFunction {
 	return nil
 }
 
-func (runtime *Runtime) SetFunc(funcname string, fn NativeFunction) {
-	runtime.Funcs[runtime.Scopes[len(runtime.Scopes)-1]][funcname] = fn
+// Declare a function in the current scope
+func (runtime *Runtime) SetFunc(fn *Function) {
+	runtime.Funcs[runtime.Scopes[len(runtime.Scopes)-1]][fn.Name] = fn
 }
 
 func (runtime *Runtime) CurrentScope() string {
@@ -111,7 +303,8 @@ func (runtime *Runtime) CurrentScope() string {
 func (runtime *Runtime) AddScope(scopename string) {
 	runtime.Scopes = append(runtime.Scopes, scopename)
 	runtime.Vars[scopename] = make(map[string]interface{})
-	runtime.Funcs[scopename] = make(map[string]NativeFunction)
+	runtime.Consts[scopename] = make(map[string]bool)
+	runtime.Funcs[scopename] = make(map[string]*Function)
 }
 
 // Exit the current scope
@@ -141,6 +334,29 @@ func (runtime *Runtime) GetMethod(calledOnValue interface{}, methodname string)
 	return methodMap[methodname]
 }
 
+// The methods of `iface` that can't be called on `value`. The methods of a
+// value are the ones in `Methods` for its type, a module's methods are its
+// functions
+func (runtime *Runtime) MissingMethods(value interface{}, iface *Interface) []string {
+	var missing []string
+	for _, name := range iface.Methods {
+		if module, isModule := value.(*Module); isModule {
+			if _, isFunction := module.Members[name].(*Function); isFunction {
+				continue
+			}
+		} else if runtime.GetMethod(value, name) != nil {
+			continue
+		}
+		missing = append(missing, name)
+	}
+	return missing
+}
+
+// Whether `value` has all methods of `iface`
+func (runtime *Runtime) Implements(value interface{}, iface *Interface) bool {
+	return len(runtime.MissingMethods(value, iface)) == 0
+}
+
 func (runtime *Runtime) SetMethod(onType reflect.Type, methodname string, method NativeFunction) {
 	methodMap, hasType := runtime.Methods[onType]
 	if !hasType {
@@ -0,0 +1,192 @@
+package stdlib
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/jomy10/nootlang/runtime"
+)
+
+// The `fs` module. Files are read and written through `runtime.FS`, so the
+// host decides which files a script can reach
+func newFsModule() *runtime.Module {
+	module := runtime.NewModule("fs")
+	path := []runtime.Param{{Name: "path"}}
+	pathAndContent := []runtime.Param{{Name: "path"}, {Name: "str"}}
+	module.SetFuncWithParams("read_to_string", path, read_to_string)
+	module.SetFuncWithParams("read_lines", path, fs__read_lines)
+	module.SetFuncWithParams("write_file", pathAndContent, fs__write_file)
+	module.SetFuncWithParams("append_file", pathAndContent, fs__append_file)
+	module.SetFuncWithParams("exists", path, fs__exists)
+	module.SetFuncWithParams("list_dir", path, fs__list_dir)
+	module.SetFuncWithParams("mkdir", path, fs__mkdir)
+	module.SetFuncWithParams("remove", path, fs__remove)
+	module.SetFuncWithParams("glob", []runtime.Param{{Name: "pattern"}}, fs__glob)
+
+	module.SetFuncWithParams("join", []runtime.Param{{Name: "parts", Variadic: true}}, fs__join)
+	module.SetFuncWithParams("basename", path, pathFunc("basename", filepath.Base))
+	module.SetFuncWithParams("dirname", path, pathFunc("dirname", filepath.Dir))
+	module.SetFuncWithParams("ext", path, pathFunc("ext", filepath.Ext))
+	return module
+}
+
+// `fs.read_lines(path)` the lines of a file without their line endings
+func fs__read_lines(r *runtime.Runtime, args []interface{}) (interface{}, error) {
+	path, err := pathArg("fs.read_lines", args)
+	if err != nil {
+		return nil, err
+	}
+	data, err := fs.ReadFile(r.FS, path)
+	if err != nil {
+		return nil, err
+	}
+
+	content := strings.TrimSuffix(string(data), "\n")
+	if content == "" {
+		return []interface{}{}, nil
+	}
+	lines := strings.Split(content, "\n")
+	arr := make([]interface{}, len(lines))
+	for i, line := range lines {
+		arr[i] = strings.TrimSuffix(line, "\r")
+	}
+	return arr, nil
+}
+
+// `fs.write_file(path, str)` creates or overwrites the file
+func fs__write_file(r *runtime.Runtime, args []interface{}) (interface{}, error) {
+	path, content, err := pathAndContentArgs("fs.write_file", args)
+	if err != nil {
+		return nil, err
+	}
+	return nil, r.FS.WriteFile(path, []byte(content))
+}
+
+// `fs.append_file(path, str)` adds to the end of the file, creating it if needed
+func fs__append_file(r *runtime.Runtime, args []interface{}) (interface{}, error) {
+	path, content, err := pathAndContentArgs("fs.append_file", args)
+	if err != nil {
+		return nil, err
+	}
+	return nil, r.FS.AppendFile(path, []byte(content))
+}
+
+// `fs.exists(path)` whether a file or directory exists
+func fs__exists(r *runtime.Runtime, args []interface{}) (interface{}, error) {
+	path, err := pathArg("fs.exists", args)
+	if err != nil {
+		return nil, err
+	}
+	_, err = fs.Stat(r.FS, path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return false, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return true, nil
+}
+
+// `fs.list_dir(path)` the sorted names of the entries in a directory
+func fs__list_dir(r *runtime.Runtime, args []interface{}) (interface{}, error) {
+	path, err := pathArg("fs.list_dir", args)
+	if err != nil {
+		return nil, err
+	}
+	entries, err := fs.ReadDir(r.FS, path)
+	if err != nil {
+		return nil, err
+	}
+
+	arr := make([]interface{}, len(entries))
+	for i, entry := range entries {
+		arr[i] = entry.Name()
+	}
+	return arr, nil
+}
+
+// `fs.mkdir(path)` creates the directory and any missing parents
+func fs__mkdir(r *runtime.Runtime, args []interface{}) (interface{}, error) {
+	path, err := pathArg("fs.mkdir", args)
+	if err != nil {
+		return nil, err
+	}
+	return nil, r.FS.MkdirAll(path)
+}
+
+// `fs.remove(path)` removes a file or an empty directory
+func fs__remove(r *runtime.Runtime, args []interface{}) (interface{}, error) {
+	path, err := pathArg("fs.remove", args)
+	if err != nil {
+		return nil, err
+	}
+	return nil, r.FS.Remove(path)
+}
+
+// `fs.glob(pattern)` the sorted paths matching a pattern such as `"src/*.noot"`
+func fs__glob(r *runtime.Runtime, args []interface{}) (interface{}, error) {
+	pattern, err := pathArg("fs.glob", args)
+	if err != nil {
+		return nil, err
+	}
+	matches, err := fs.Glob(r.FS, pattern)
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(matches)
+
+	arr := make([]interface{}, len(matches))
+	for i, match := range matches {
+		arr[i] = match
+	}
+	return arr, nil
+}
+
+// `fs.join(parts...)` joins path elements with the separator
+func fs__join(_ *runtime.Runtime, args []interface{}) (interface{}, error) {
+	parts := make([]string, len(args))
+	for i, arg := range args {
+		part, ok := arg.(string)
+		if !ok {
+			return nil, errors.New(fmt.Sprintf("`fs.join` expects strings, but got %v", arg))
+		}
+		parts[i] = part
+	}
+	return filepath.Join(parts...), nil
+}
+
+// A function that takes a path and returns part of it
+func pathFunc(name string, fn func(string) string) runtime.NativeFunction {
+	return func(_ *runtime.Runtime, args []interface{}) (interface{}, error) {
+		path, err := pathArg("fs."+name, args)
+		if err != nil {
+			return nil, err
+		}
+		return fn(path), nil
+	}
+}
+
+// The first argument of a function that takes a path
+func pathArg(name string, args []interface{}) (string, error) {
+	path, ok := args[0].(string)
+	if !ok {
+		return "", errors.New(fmt.Sprintf("`%s` expects a string path, but got %v", name, args[0]))
+	}
+	return path, nil
+}
+
+func pathAndContentArgs(name string, args []interface{}) (string, string, error) {
+	path, err := pathArg(name, args)
+	if err != nil {
+		return "", "", err
+	}
+	content, ok := args[1].(string)
+	if !ok {
+		return "", "", errors.New(fmt.Sprintf("`%s` expects a string to write, but got %v", name, args[1]))
+	}
+	return path, content, nil
+}
@@ -0,0 +1,78 @@
+package stdlib
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/jomy10/nootlang/runtime"
+)
+
+// Functions that read `runtime.Stdin`, through the runtime's shared buffered
+// reader. Lines are returned without their line ending
+func registerInput(r *runtime.Runtime) {
+	r.Funcs["GLOBAL"]["input"] = runtime.NewFunctionWithParams("input", []runtime.Param{{Name: "prompt", Optional: true}}, input)
+	r.Funcs["GLOBAL"]["read_line"] = runtime.NewFunctionWithParams("read_line", []runtime.Param{}, read_line)
+	r.Funcs["GLOBAL"]["read_all"] = runtime.NewFunctionWithParams("read_all", []runtime.Param{}, read_all)
+	r.Funcs["GLOBAL"]["lines"] = runtime.NewFunctionWithParams("lines", []runtime.Param{}, lines)
+}
+
+// `input(prompt?)` writes the prompt to stdout and reads a line. Returns nil
+// at the end of the input
+func input(r *runtime.Runtime, args []interface{}) (interface{}, error) {
+	if args[0] != nil {
+		prompt, ok := args[0].(string)
+		if !ok {
+			return nil, errors.New(fmt.Sprintf("`input` expects a string prompt, but got %v", args[0]))
+		}
+		if _, err := io.WriteString(r.Stdout, prompt); err != nil {
+			return nil, err
+		}
+	}
+	return readLine(r)
+}
+
+// `read_line()` reads a line, or returns nil at the end of the input
+func read_line(r *runtime.Runtime, args []interface{}) (interface{}, error) {
+	return readLine(r)
+}
+
+// `read_all()` reads the rest of the input
+func read_all(r *runtime.Runtime, args []interface{}) (interface{}, error) {
+	data, err := io.ReadAll(r.StdinReader())
+	if err != nil {
+		return nil, err
+	}
+	return string(data), nil
+}
+
+// `lines()` reads the rest of the input as an array of lines. Like `read_all`
+// it only returns once the input has ended
+func lines(r *runtime.Runtime, args []interface{}) (interface{}, error) {
+	arr := []interface{}{}
+	for {
+		line, err := readLine(r)
+		if err != nil {
+			return nil, err
+		}
+		if line == nil {
+			return arr, nil
+		}
+		arr = append(arr, line)
+	}
+}
+
+// The next line without its line ending, or nil if there is no more input
+func readLine(r *runtime.Runtime) (interface{}, error) {
+	line, err := r.StdinReader().ReadString('\n')
+	if err == io.EOF {
+		if line == "" {
+			return nil, nil
+		}
+	} else if err != nil {
+		return nil, err
+	}
+	line = strings.TrimSuffix(line, "\n")
+	return strings.TrimSuffix(line, "\r"), nil
+}
@@ -0,0 +1,222 @@
+package stdlib
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/jomy10/nootlang/runtime"
+)
+
+// The `json` module.
+//
+// JSON values map to noot values as follows:
+//
+//	object         map (string keys)
+//	array          array (tuples are written as arrays)
+//	number         integer if it has no fraction or exponent and fits in 64
+//	               bits, float otherwise
+//	string         string
+//	true, false    bool
+//	null           nil
+//
+// `stringify` does the reverse. Floats are always written with a fraction
+// (`2.0`), so they are parsed back as floats
+func newJsonModule() *runtime.Module {
+	module := runtime.NewModule("json")
+	module.SetFuncWithParams("parse", []runtime.Param{{Name: "str"}}, json__parse)
+	module.SetFuncWithParams("stringify", []runtime.Param{{Name: "value"}, {Name: "indent", Optional: true}}, json__stringify)
+	return module
+}
+
+// `json.parse(str)`
+func json__parse(_ *runtime.Runtime, args []interface{}) (interface{}, error) {
+	source, ok := args[0].(string)
+	if !ok {
+		return nil, errors.New(fmt.Sprintf("`json.parse` expects a string, but got %v", args[0]))
+	}
+
+	decoder := json.NewDecoder(strings.NewReader(source))
+	decoder.UseNumber()
+
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return nil, jsonError(source, err)
+	}
+	// Only whitespace may follow the value
+	rest := strings.TrimLeft(source[decoder.InputOffset():], " \t\r\n")
+	if rest != "" {
+		return nil, jsonErrorAt(source, "unexpected data after the value", len(source)-len(rest))
+	}
+
+	return fromJson(value)
+}
+
+// `json.stringify(value, indent?)` where indent is the amount of spaces or the
+// string used to indent nested values. Without an indent the JSON is written on
+// a single line
+func json__stringify(_ *runtime.Runtime, args []interface{}) (interface{}, error) {
+	if runtime.ContainsItself(args[0]) {
+		return nil, errors.New("`json.stringify` cannot convert a value that contains itself")
+	}
+	var buf bytes.Buffer
+	if err := writeJson(&buf, args[0]); err != nil {
+		return nil, err
+	}
+	if args[1] == nil {
+		return buf.String(), nil
+	}
+
+	var indent string
+	switch args[1].(type) {
+	case int64:
+		if args[1].(int64) < 0 {
+			return nil, errors.New(fmt.Sprintf("`json.stringify` expects an indent of 0 or more, but got %d", args[1]))
+		}
+		indent = strings.Repeat(" ", int(args[1].(int64)))
+	case string:
+		indent = args[1].(string)
+	default:
+		return nil, errors.New(fmt.Sprintf("`json.stringify` expects an integer or string as indent, but got %v", args[1]))
+	}
+
+	var indented bytes.Buffer
+	if err := json.Indent(&indented, buf.Bytes(), "", indent); err != nil {
+		return nil, err
+	}
+	return indented.String(), nil
+}
+
+// Converts the numbers from `encoding/json` to integers and floats
+func fromJson(value interface{}) (interface{}, error) {
+	switch value.(type) {
+	case json.Number:
+		number := value.(json.Number).String()
+		if !strings.ContainsAny(number, ".eE") {
+			if integer, err := strconv.ParseInt(number, 10, 64); err == nil {
+				return integer, nil
+			}
+		}
+		return strconv.ParseFloat(number, 64)
+	case []interface{}:
+		arr := value.([]interface{})
+		for i, element := range arr {
+			converted, err := fromJson(element)
+			if err != nil {
+				return nil, err
+			}
+			arr[i] = converted
+		}
+		return arr, nil
+	case map[string]interface{}:
+		object := value.(map[string]interface{})
+		for key, element := range object {
+			converted, err := fromJson(element)
+			if err != nil {
+				return nil, err
+			}
+			object[key] = converted
+		}
+		return object, nil
+	default:
+		return value, nil
+	}
+}
+
+func writeJson(buf *bytes.Buffer, value interface{}) error {
+	switch value.(type) {
+	case nil:
+		buf.WriteString("null")
+	case bool:
+		buf.WriteString(strconv.FormatBool(value.(bool)))
+	case int64:
+		buf.WriteString(strconv.FormatInt(value.(int64), 10))
+	case float64:
+		f := value.(float64)
+		if math.IsNaN(f) || math.IsInf(f, 0) {
+			return errors.New(fmt.Sprintf("`json.stringify` cannot write %v, JSON has no such number", f))
+		}
+		str := strconv.FormatFloat(f, 'g', -1, 64)
+		if !strings.ContainsAny(str, ".e") {
+			str += ".0"
+		}
+		buf.WriteString(str)
+	case string:
+		writeJsonString(buf, value.(string))
+	case runtime.Tuple:
+		return writeJson(buf, []interface{}(value.(runtime.Tuple)))
+	case []interface{}:
+		buf.WriteByte('[')
+		for i, element := range value.([]interface{}) {
+			if i != 0 {
+				buf.WriteByte(',')
+			}
+			if err := writeJson(buf, element); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte(']')
+	case map[string]interface{}:
+		object := value.(map[string]interface{})
+		keys := make([]string, 0, len(object))
+		for key := range object {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		buf.WriteByte('{')
+		for i, key := range keys {
+			if i != 0 {
+				buf.WriteByte(',')
+			}
+			writeJsonString(buf, key)
+			buf.WriteByte(':')
+			if err := writeJson(buf, object[key]); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte('}')
+	default:
+		return errors.New(fmt.Sprintf("`json.stringify` cannot convert %v to JSON", value))
+	}
+	return nil
+}
+
+func writeJsonString(buf *bytes.Buffer, str string) {
+	encoder := json.NewEncoder(buf)
+	encoder.SetEscapeHTML(false)
+	encoder.Encode(str)
+	buf.Truncate(buf.Len() - 1) // Encode adds a newline
+}
+
+// Converts an error from `encoding/json` to an error with its position
+func jsonError(source string, err error) error {
+	switch {
+	case errors.Is(err, io.EOF), errors.Is(err, io.ErrUnexpectedEOF):
+		return jsonErrorAt(source, "unexpected end of input", len(source))
+	default:
+		syntaxErr, isSyntaxErr := err.(*json.SyntaxError)
+		if !isSyntaxErr {
+			return err
+		}
+		// The offset is right after the offending character
+		offset := int(syntaxErr.Offset) - 1
+		if offset < 0 {
+			offset = 0
+		}
+		return jsonErrorAt(source, syntaxErr.Error(), offset)
+	}
+}
+
+// An error that points at the line and column of `offset` in the source
+func jsonErrorAt(source string, msg string, offset int) error {
+	line := strings.Count(source[:offset], "\n") + 1
+	column := offset - strings.LastIndex(source[:offset], "\n")
+	return errors.New(fmt.Sprintf("`json.parse`: %s at line %d, column %d (offset %d)", msg, line, column, offset))
+}
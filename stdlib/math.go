@@ -0,0 +1,368 @@
+package stdlib
+
+import (
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/jomy10/nootlang/runtime"
+)
+
+// The `math` module. Functions that take numbers accept both integers and
+// floats. Random numbers come from `runtime.Rand`, so the host decides the seed
+func newMathModule() *runtime.Module {
+	module := runtime.NewModule("math")
+	module.Members["pi"] = math.Pi
+	module.Members["e"] = math.E
+
+	x := []runtime.Param{{Name: "x"}}
+	numbers := []runtime.Param{{Name: "x"}, {Name: "rest", Variadic: true}}
+	module.SetFuncWithParams("abs", x, math__abs)
+	module.SetFuncWithParams("min", numbers, math__min)
+	module.SetFuncWithParams("max", numbers, math__max)
+	module.SetFuncWithParams("pow", []runtime.Param{{Name: "x"}, {Name: "y"}}, math__pow)
+	module.SetFuncWithParams("sqrt", x, floatFunc("sqrt", math.Sqrt))
+	module.SetFuncWithParams("floor", x, roundFunc("floor", math.Floor))
+	module.SetFuncWithParams("ceil", x, roundFunc("ceil", math.Ceil))
+	module.SetFuncWithParams("round", x, roundFunc("round", math.Round))
+	module.SetFuncWithParams("gcd", numbers, math__gcd)
+	module.SetFuncWithParams("lcm", numbers, math__lcm)
+	module.SetFuncWithParams("clamp", []runtime.Param{{Name: "x"}, {Name: "min"}, {Name: "max"}}, math__clamp)
+
+	module.SetFuncWithParams("sin", x, floatFunc("sin", math.Sin))
+	module.SetFuncWithParams("cos", x, floatFunc("cos", math.Cos))
+	module.SetFuncWithParams("tan", x, floatFunc("tan", math.Tan))
+	module.SetFuncWithParams("asin", x, floatFunc("asin", math.Asin))
+	module.SetFuncWithParams("acos", x, floatFunc("acos", math.Acos))
+	module.SetFuncWithParams("atan", x, floatFunc("atan", math.Atan))
+	module.SetFuncWithParams("atan2", []runtime.Param{{Name: "y"}, {Name: "x"}}, math__atan2)
+
+	module.SetFuncWithParams("rand_int", []runtime.Param{{Name: "min"}, {Name: "max"}}, math__rand_int)
+	module.SetFuncWithParams("rand_float", []runtime.Param{}, math__rand_float)
+	module.SetFuncWithParams("shuffle", []runtime.Param{{Name: "array"}}, math__shuffle)
+	module.SetFuncWithParams("choice", []runtime.Param{{Name: "array"}}, math__choice)
+	return module
+}
+
+// `math.abs(x)`, keeps integers as integers
+func math__abs(_ *runtime.Runtime, args []interface{}) (interface{}, error) {
+	switch args[0].(type) {
+	case int64:
+		if args[0].(int64) == math.MinInt64 {
+			return nil, errors.New(fmt.Sprintf("`math.abs`: the absolute value of %d doesn't fit in an integer", args[0]))
+		}
+		return abs(args[0].(int64)), nil
+	case float64:
+		return math.Abs(args[0].(float64)), nil
+	default:
+		return nil, errors.New(fmt.Sprintf("`math.abs` expects a number, but got %v", args[0]))
+	}
+}
+
+// `math.min(numbers...)` returns the smallest of its arguments
+func math__min(_ *runtime.Runtime, args []interface{}) (interface{}, error) {
+	return extreme("math.min", args, func(order int) bool { return order < 0 })
+}
+
+// `math.max(numbers...)` returns the largest of its arguments
+func math__max(_ *runtime.Runtime, args []interface{}) (interface{}, error) {
+	return extreme("math.max", args, func(order int) bool { return order > 0 })
+}
+
+// `better` gets the order of an argument compared to the best one so far
+func extreme(name string, args []interface{}, better func(int) bool) (interface{}, error) {
+
+	result := args[0]
+	if _, err := number(name, result); err != nil {
+		return nil, err
+	}
+	for _, arg := range args[1:] {
+		order, err := compareNumbers(name, arg, result)
+		if err != nil {
+			return nil, err
+		}
+		if better(order) {
+			result = arg
+		}
+	}
+	return result, nil
+}
+
+// Returns -1 if `a` is smaller than `b`, 1 if it is larger and 0 otherwise.
+// Integers are compared as integers, because large ones don't fit in a float
+func compareNumbers(name string, a interface{}, b interface{}) (int, error) {
+	intA, aIsInt := a.(int64)
+	intB, bIsInt := b.(int64)
+	if aIsInt && bIsInt {
+		switch {
+		case intA < intB:
+			return -1, nil
+		case intA > intB:
+			return 1, nil
+		}
+		return 0, nil
+	}
+
+	x, err := number(name, a)
+	if err != nil {
+		return 0, err
+	}
+	y, err := number(name, b)
+	if err != nil {
+		return 0, err
+	}
+	switch {
+	case x < y:
+		return -1, nil
+	case x > y:
+		return 1, nil
+	}
+	return 0, nil
+}
+
+// `math.pow(x, y)` is an integer if both arguments are integers and `y` isn't
+// negative
+func math__pow(_ *runtime.Runtime, args []interface{}) (interface{}, error) {
+
+	base, baseIsInt := args[0].(int64)
+	exponent, exponentIsInt := args[1].(int64)
+	if baseIsInt && exponentIsInt && exponent >= 0 {
+		result, ok := intPow(base, exponent)
+		if !ok {
+			return nil, errors.New(fmt.Sprintf("`math.pow`: %d to the power of %d doesn't fit in an integer", base, exponent))
+		}
+		return result, nil
+	}
+
+	x, err := number("math.pow", args[0])
+	if err != nil {
+		return nil, err
+	}
+	y, err := number("math.pow", args[1])
+	if err != nil {
+		return nil, err
+	}
+	return math.Pow(x, y), nil
+}
+
+// Exponentiation by squaring, `ok` is false if the result overflows
+func intPow(base int64, exponent int64) (result int64, ok bool) {
+	result = 1
+	for exponent > 0 {
+		if exponent&1 == 1 {
+			if result, ok = multiply(result, base); !ok {
+				return 0, false
+			}
+		}
+		exponent >>= 1
+		if exponent == 0 {
+			break
+		}
+		if base, ok = multiply(base, base); !ok {
+			return 0, false
+		}
+	}
+	return result, true
+}
+
+// Multiplies two integers, `ok` is false if the result overflows
+func multiply(a int64, b int64) (int64, bool) {
+	if a == 0 || b == 0 {
+		return 0, true
+	}
+	if (a == -1 && b == math.MinInt64) || (b == -1 && a == math.MinInt64) {
+		return 0, false
+	}
+	product := a * b
+	return product, product/b == a
+}
+
+// `math.atan2(y, x)`
+func math__atan2(_ *runtime.Runtime, args []interface{}) (interface{}, error) {
+	y, err := number("math.atan2", args[0])
+	if err != nil {
+		return nil, err
+	}
+	x, err := number("math.atan2", args[1])
+	if err != nil {
+		return nil, err
+	}
+	return math.Atan2(y, x), nil
+}
+
+// `math.gcd(a, b, ...)` the greatest common divisor of integers
+func math__gcd(_ *runtime.Runtime, args []interface{}) (interface{}, error) {
+	return foldIntegers("math.gcd", args, func(a int64, b int64) (int64, bool) {
+		return gcd(a, b), true
+	})
+}
+
+// `math.lcm(a, b, ...)` the least common multiple of integers
+func math__lcm(_ *runtime.Runtime, args []interface{}) (interface{}, error) {
+	return foldIntegers("math.lcm", args, func(a int64, b int64) (int64, bool) {
+		if a == 0 || b == 0 {
+			return 0, true
+		}
+		product, ok := multiply(a/gcd(a, b), b)
+		return abs(product), ok
+	})
+}
+
+// Folds the integers in `args` with `fn`, which returns false if its result
+// overflows. The result is never negative
+func foldIntegers(name string, args []interface{}, fn func(int64, int64) (int64, bool)) (interface{}, error) {
+
+	var result int64
+	for i, arg := range args {
+		n, ok := arg.(int64)
+		if !ok {
+			return nil, errors.New(fmt.Sprintf("`%s` expects integers, but got %v", name, arg))
+		}
+		if i == 0 {
+			result = n
+		} else if result, ok = fn(result, n); !ok {
+			return nil, errors.New(fmt.Sprintf("`%s`: the result doesn't fit in an integer", name))
+		}
+	}
+	// The absolute value of the smallest integer is one more than the largest
+	if result == math.MinInt64 {
+		return nil, errors.New(fmt.Sprintf("`%s`: the result doesn't fit in an integer", name))
+	}
+	return abs(result), nil
+}
+
+// The greatest common divisor, which is the smallest integer when it is 2^63
+func gcd(a int64, b int64) int64 {
+	for b != 0 {
+		a, b = b, a%b
+	}
+	return abs(a)
+}
+
+func abs(n int64) int64 {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+// `math.clamp(x, min, max)` limits an integer to the range min..max
+func math__clamp(_ *runtime.Runtime, args []interface{}) (interface{}, error) {
+	x, xOk := args[0].(int64)
+	min, minOk := args[1].(int64)
+	max, maxOk := args[2].(int64)
+	if !xOk || !minOk || !maxOk {
+		return nil, errors.New("`math.clamp` expects integers")
+	}
+	if min > max {
+		return nil, errors.New(fmt.Sprintf("`math.clamp` got a minimum (%d) larger than its maximum (%d)", min, max))
+	}
+
+	if x < min {
+		return min, nil
+	}
+	if x > max {
+		return max, nil
+	}
+	return x, nil
+}
+
+// `math.rand_int(min, max)` a random integer from min up to and including max
+func math__rand_int(r *runtime.Runtime, args []interface{}) (interface{}, error) {
+	min, minOk := args[0].(int64)
+	max, maxOk := args[1].(int64)
+	if !minOk || !maxOk {
+		return nil, errors.New("`math.rand_int` expects integers")
+	}
+	if min > max {
+		return nil, errors.New(fmt.Sprintf("`math.rand_int` got a minimum (%d) larger than its maximum (%d)", min, max))
+	}
+	// The amount of integers in the range, 0 if it is all 2^64 of them
+	count := uint64(max) - uint64(min) + 1
+	switch {
+	case count == 0:
+		return int64(r.Rand.Uint64()), nil
+	case count <= math.MaxInt64:
+		return min + r.Rand.Int63n(int64(count)), nil
+	}
+	// Numbers from `limit` up are drawn again, so every integer in the range is
+	// as likely
+	limit := math.MaxUint64 - math.MaxUint64%count
+	for {
+		if n := r.Rand.Uint64(); n < limit {
+			return int64(uint64(min) + n%count), nil
+		}
+	}
+}
+
+// `math.rand_float()` a random float in the range [0, 1)
+func math__rand_float(r *runtime.Runtime, args []interface{}) (interface{}, error) {
+	return r.Rand.Float64(), nil
+}
+
+// `math.shuffle(array)` returns the elements of the array in a random order
+func math__shuffle(r *runtime.Runtime, args []interface{}) (interface{}, error) {
+	arr, ok := args[0].([]interface{})
+	if !ok {
+		return nil, errors.New(fmt.Sprintf("`math.shuffle` expects an array, but got %v", args[0]))
+	}
+
+	shuffled := append([]interface{}{}, arr...)
+	r.Rand.Shuffle(len(shuffled), func(i, j int) {
+		shuffled[i], shuffled[j] = shuffled[j], shuffled[i]
+	})
+	return shuffled, nil
+}
+
+// `math.choice(array)` returns a random element of the array
+func math__choice(r *runtime.Runtime, args []interface{}) (interface{}, error) {
+	arr, ok := args[0].([]interface{})
+	if !ok {
+		return nil, errors.New(fmt.Sprintf("`math.choice` expects an array, but got %v", args[0]))
+	}
+	if len(arr) == 0 {
+		return nil, errors.New("`math.choice` got an empty array")
+	}
+	return arr[r.Rand.Intn(len(arr))], nil
+}
+
+// A function of one number that returns a float
+func floatFunc(name string, fn func(float64) float64) runtime.NativeFunction {
+	return func(_ *runtime.Runtime, args []interface{}) (interface{}, error) {
+		x, err := number("math."+name, args[0])
+		if err != nil {
+			return nil, err
+		}
+		return fn(x), nil
+	}
+}
+
+// A function that rounds a number to an integer
+func roundFunc(name string, fn func(float64) float64) runtime.NativeFunction {
+	return func(_ *runtime.Runtime, args []interface{}) (interface{}, error) {
+		switch args[0].(type) {
+		case int64:
+			return args[0], nil
+		case float64:
+			f := fn(args[0].(float64))
+			if math.IsNaN(f) || f < math.MinInt64 || f >= math.MaxInt64 {
+				return nil, errors.New(fmt.Sprintf("`math.%s`: %v doesn't fit in an integer", name, args[0]))
+			}
+			return int64(f), nil
+		default:
+			return nil, errors.New(fmt.Sprintf("`math.%s` expects a number, but got %v", name, args[0]))
+		}
+	}
+}
+
+// An integer or float argument as a float
+func number(name string, value interface{}) (float64, error) {
+	switch value.(type) {
+	case int64:
+		return float64(value.(int64)), nil
+	case float64:
+		return value.(float64), nil
+	default:
+		return 0, errors.New(fmt.Sprintf("`%s` expects a number, but got %v", name, value))
+	}
+}
@@ -0,0 +1,260 @@
+package stdlib
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strconv"
+	"unicode/utf8"
+
+	"github.com/jomy10/nootlang/runtime"
+)
+
+// `regex(pattern)` compiles a regular expression (Go's RE2 syntax). String
+// methods that take a pattern accept both a compiled regex and a string
+func registerRegex(r *runtime.Runtime) {
+	r.Funcs["GLOBAL"]["regex"] = runtime.NewFunctionWithParams("regex", []runtime.Param{{Name: "pattern"}}, regex)
+
+	string_type := reflect.TypeOf("")
+	if _, ok := r.Methods[string_type]; !ok {
+		r.Methods[string_type] = make(map[string]runtime.NativeFunction)
+	}
+	r.Methods[string_type]["match_indices"] = string__match_indices
+	r.Methods[string_type]["submatch"] = string__submatch
+
+	regex_type := reflect.TypeOf(&regexp.Regexp{})
+	r.Methods[regex_type] = make(map[string]runtime.NativeFunction)
+	r.Methods[regex_type]["match"] = regex__match
+	r.Methods[regex_type]["find"] = regex__find
+	r.Methods[regex_type]["find_all"] = regex__find_all
+	r.Methods[regex_type]["replace"] = regex__replace
+	r.Methods[regex_type]["split"] = regex__split
+	r.Methods[regex_type]["captures"] = regex__captures
+}
+
+func regex(_ *runtime.Runtime, args []interface{}) (interface{}, error) {
+	pattern, ok := args[0].(string)
+	if !ok {
+		return nil, errors.New(fmt.Sprintf("`regex` expects a string, but got %v", args[0]))
+	}
+	return regexp.Compile(pattern)
+}
+
+// The argument at index `i` as a regex, compiling it if it is a string
+func regexArg(method string, args []interface{}, i int) (*regexp.Regexp, error) {
+	switch args[i].(type) {
+	case string:
+		return regexp.Compile(args[i].(string))
+	case *regexp.Regexp:
+		return args[i].(*regexp.Regexp), nil
+	default:
+		return nil, errors.New(fmt.Sprintf("Invalid argument type in `%s`, expected a regex or string", method))
+	}
+}
+
+// The string argument of a regex method
+func subjectArg(method string, args []interface{}) (string, error) {
+	str, ok := args[1].(string)
+	if !ok {
+		return "", errors.New(fmt.Sprintf("`%s` expects a string, but got %v", method, args[1]))
+	}
+	return str, nil
+}
+
+// string.match_indices(re) returns a `(start, end)` tuple for every match.
+// Like the other string methods, it counts in characters rather than bytes
+func string__match_indices(_ *runtime.Runtime, args []interface{}) (interface{}, error) {
+	if len(args) != 2 {
+		return nil, errors.New("`string.match_indices` expects 1 argument")
+	}
+
+	str, ok := args[0].(string)
+	if !ok {
+		return nil, errors.New("interpreter error")
+	}
+
+	regex, err := regexArg("string.match_indices", args, 1)
+	if err != nil {
+		return nil, err
+	}
+
+	result := regex.FindAllStringIndex(str, -1)
+	arr := make([]interface{}, len(result))
+	// The matches are in order, so the characters are counted from the
+	// previous offset on
+	offset, chars := 0, 0
+	charIndex := func(byteIndex int) int64 {
+		chars += utf8.RuneCountInString(str[offset:byteIndex])
+		offset = byteIndex
+		return int64(chars)
+	}
+	for i, element := range result {
+		start := charIndex(element[0])
+		arr[i] = runtime.Tuple{start, charIndex(element[1])}
+	}
+	return arr, nil
+}
+
+func string__submatch(runtime *runtime.Runtime, args []interface{}) (interface{}, error) {
+	if len(args) != 2 {
+		return nil, errors.New("`string.submatch` expects 1 argument")
+	}
+
+	str, ok := args[0].(string)
+	if !ok {
+		return nil, errors.New("interpreter error")
+	}
+
+	regex, err := regexArg("string.submatch", args, 1)
+	if err != nil {
+		return nil, err
+	}
+
+	result := regex.FindAllStringSubmatch(str, -1)
+	arr := make([]interface{}, len(result))
+	for i, element := range result {
+		arr[i] = make([]interface{}, len(element))
+		for j, e := range element {
+			arr[i].([]interface{})[j] = e
+		}
+	}
+	return arr, nil
+}
+
+// regex.match(str) returns whether the regex matches somewhere in the string
+func regex__match(_ *runtime.Runtime, args []interface{}) (interface{}, error) {
+	if len(args) != 2 {
+		return nil, errors.New("`regex.match` expects 1 argument")
+	}
+	str, err := subjectArg("regex.match", args)
+	if err != nil {
+		return nil, err
+	}
+	return args[0].(*regexp.Regexp).MatchString(str), nil
+}
+
+// regex.find(str) returns the first match, or nil if there is none
+func regex__find(_ *runtime.Runtime, args []interface{}) (interface{}, error) {
+	if len(args) != 2 {
+		return nil, errors.New("`regex.find` expects 1 argument")
+	}
+	str, err := subjectArg("regex.find", args)
+	if err != nil {
+		return nil, err
+	}
+
+	loc := args[0].(*regexp.Regexp).FindStringIndex(str)
+	if loc == nil {
+		return nil, nil
+	}
+	return str[loc[0]:loc[1]], nil
+}
+
+// regex.find_all(str, n?) returns an array of all matches, or of the first `n`
+func regex__find_all(_ *runtime.Runtime, args []interface{}) (interface{}, error) {
+	if len(args) != 2 && len(args) != 3 {
+		return nil, errors.New("`regex.find_all` expects 1 or 2 arguments")
+	}
+	str, err := subjectArg("regex.find_all", args)
+	if err != nil {
+		return nil, err
+	}
+	n, err := countArg("regex.find_all", args)
+	if err != nil {
+		return nil, err
+	}
+
+	matches := args[0].(*regexp.Regexp).FindAllString(str, n)
+	arr := make([]interface{}, len(matches))
+	for i, match := range matches {
+		arr[i] = match
+	}
+	return arr, nil
+}
+
+// regex.replace(str, replacement) replaces all matches. `$1` or `${name}` in
+// the replacement is replaced by the text of that group
+func regex__replace(_ *runtime.Runtime, args []interface{}) (interface{}, error) {
+	if len(args) != 3 {
+		return nil, errors.New("`regex.replace` expects 2 arguments")
+	}
+	str, err := subjectArg("regex.replace", args)
+	if err != nil {
+		return nil, err
+	}
+	replacement, ok := args[2].(string)
+	if !ok {
+		return nil, errors.New(fmt.Sprintf("`regex.replace` expects a string replacement, but got %v", args[2]))
+	}
+	return args[0].(*regexp.Regexp).ReplaceAllString(str, replacement), nil
+}
+
+// regex.split(str, n?) splits the string around the matches into at most `n`
+// parts, or all of them
+func regex__split(_ *runtime.Runtime, args []interface{}) (interface{}, error) {
+	if len(args) != 2 && len(args) != 3 {
+		return nil, errors.New("`regex.split` expects 1 or 2 arguments")
+	}
+	str, err := subjectArg("regex.split", args)
+	if err != nil {
+		return nil, err
+	}
+	n, err := countArg("regex.split", args)
+	if err != nil {
+		return nil, err
+	}
+
+	parts := args[0].(*regexp.Regexp).Split(str, n)
+	arr := make([]interface{}, len(parts))
+	for i, part := range parts {
+		arr[i] = part
+	}
+	return arr, nil
+}
+
+// regex.captures(str) returns the groups of the first match as a map, or nil
+// if it doesn't match. Every group is stored by its number ("0" is the whole
+// match) and named groups (`(?P<name>...)`) by their name as well. Groups that
+// didn't take part in the match are nil
+func regex__captures(_ *runtime.Runtime, args []interface{}) (interface{}, error) {
+	if len(args) != 2 {
+		return nil, errors.New("`regex.captures` expects 1 argument")
+	}
+	str, err := subjectArg("regex.captures", args)
+	if err != nil {
+		return nil, err
+	}
+
+	regex := args[0].(*regexp.Regexp)
+	loc := regex.FindStringSubmatchIndex(str)
+	if loc == nil {
+		return nil, nil
+	}
+
+	captures := make(map[string]interface{})
+	for i, name := range regex.SubexpNames() {
+		var group interface{}
+		if loc[2*i] != -1 {
+			group = str[loc[2*i]:loc[2*i+1]]
+		}
+		captures[strconv.Itoa(i)] = group
+		if name != "" {
+			captures[name] = group
+		}
+	}
+	return captures, nil
+}
+
+// The optional maximum amount of results after the string argument, -1 (no
+// maximum) if it isn't given
+func countArg(method string, args []interface{}) (int, error) {
+	if len(args) != 3 {
+		return -1, nil
+	}
+	n, ok := args[2].(int64)
+	if !ok {
+		return 0, errors.New(fmt.Sprintf("`%s` expects an integer count, but got %v", method, args[2]))
+	}
+	return int(n), nil
+}
@@ -0,0 +1,293 @@
+package stdlib
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"time"
+
+	"github.com/jomy10/nootlang/runtime"
+)
+
+// The `time` module. Times and durations are their own types with methods.
+// The current time comes from `runtime.Clock`, so the host can freeze it, and
+// `sleep` stops when the runtime's context is cancelled
+//
+// Layouts are Go's reference time layouts (`2006-01-02 15:04:05`)
+func newTimeModule() *runtime.Module {
+	module := runtime.NewModule("time")
+	module.Members["rfc3339"] = time.RFC3339
+	module.Members["date"] = "2006-01-02"
+	module.Members["clock"] = "15:04:05"
+	module.Members["datetime"] = "2006-01-02 15:04:05"
+
+	module.SetFuncWithParams("now", []runtime.Param{}, time__now)
+	module.SetFuncWithParams("unix", []runtime.Param{}, time__unix)
+	module.SetFuncWithParams("from_unix", []runtime.Param{{Name: "seconds"}}, time__from_unix)
+	module.SetFuncWithParams("parse", []runtime.Param{{Name: "str"}, {Name: "layout", Optional: true}}, time__parse)
+	module.SetFuncWithParams("since", []runtime.Param{{Name: "t"}}, time__since)
+	module.SetFuncWithParams("sleep", []runtime.Param{{Name: "duration"}}, time__sleep)
+
+	amount := []runtime.Param{{Name: "amount"}}
+	module.SetFuncWithParams("duration", []runtime.Param{{Name: "str"}}, time__duration)
+	module.SetFuncWithParams("milliseconds", amount, durationFunc("milliseconds", time.Millisecond))
+	module.SetFuncWithParams("seconds", amount, durationFunc("seconds", time.Second))
+	module.SetFuncWithParams("minutes", amount, durationFunc("minutes", time.Minute))
+	module.SetFuncWithParams("hours", amount, durationFunc("hours", time.Hour))
+	return module
+}
+
+func registerTimeMethods(r *runtime.Runtime) {
+	time_type := reflect.TypeOf(time.Time{})
+	r.Methods[time_type] = make(map[string]runtime.NativeFunction)
+	r.Methods[time_type]["unix"] = time__time_unix
+	r.Methods[time_type]["unix_milli"] = time__time_unix_milli
+	r.Methods[time_type]["format"] = time__time_format
+	r.Methods[time_type]["year"] = timeField("year", func(t time.Time) interface{} { return int64(t.Year()) })
+	r.Methods[time_type]["month"] = timeField("month", func(t time.Time) interface{} { return int64(t.Month()) })
+	r.Methods[time_type]["day"] = timeField("day", func(t time.Time) interface{} { return int64(t.Day()) })
+	r.Methods[time_type]["hour"] = timeField("hour", func(t time.Time) interface{} { return int64(t.Hour()) })
+	r.Methods[time_type]["minute"] = timeField("minute", func(t time.Time) interface{} { return int64(t.Minute()) })
+	r.Methods[time_type]["second"] = timeField("second", func(t time.Time) interface{} { return int64(t.Second()) })
+	r.Methods[time_type]["weekday"] = timeField("weekday", func(t time.Time) interface{} { return t.Weekday().String() })
+	r.Methods[time_type]["utc"] = timeField("utc", func(t time.Time) interface{} { return t.UTC() })
+	r.Methods[time_type]["add"] = time__time_add
+	r.Methods[time_type]["sub"] = time__time_sub
+	r.Methods[time_type]["before"] = timeComparison("before", time.Time.Before)
+	r.Methods[time_type]["after"] = timeComparison("after", time.Time.After)
+	r.Methods[time_type]["equal"] = timeComparison("equal", time.Time.Equal)
+
+	duration_type := reflect.TypeOf(time.Duration(0))
+	r.Methods[duration_type] = make(map[string]runtime.NativeFunction)
+	r.Methods[duration_type]["milliseconds"] = durationField("milliseconds", func(d time.Duration) interface{} { return d.Milliseconds() })
+	r.Methods[duration_type]["seconds"] = durationField("seconds", func(d time.Duration) interface{} { return d.Seconds() })
+	r.Methods[duration_type]["minutes"] = durationField("minutes", func(d time.Duration) interface{} { return d.Minutes() })
+	r.Methods[duration_type]["hours"] = durationField("hours", func(d time.Duration) interface{} { return d.Hours() })
+	r.Methods[duration_type]["string"] = durationField("string", func(d time.Duration) interface{} { return d.String() })
+	r.Methods[duration_type]["add"] = durationArithmetic("add", func(a, b time.Duration) time.Duration { return a + b })
+	r.Methods[duration_type]["sub"] = durationArithmetic("sub", func(a, b time.Duration) time.Duration { return a - b })
+	r.Methods[duration_type]["mul"] = time__duration_mul
+}
+
+// `time.now()`
+func time__now(r *runtime.Runtime, args []interface{}) (interface{}, error) {
+	return r.Clock.Now(), nil
+}
+
+// `time.unix()` the current time in seconds since January 1, 1970 UTC
+func time__unix(r *runtime.Runtime, args []interface{}) (interface{}, error) {
+	return r.Clock.Now().Unix(), nil
+}
+
+// `time.from_unix(seconds)` the UTC time of a unix timestamp
+func time__from_unix(_ *runtime.Runtime, args []interface{}) (interface{}, error) {
+	seconds, ok := args[0].(int64)
+	if !ok {
+		return nil, errors.New(fmt.Sprintf("`time.from_unix` expects an integer, but got %v", args[0]))
+	}
+	return time.Unix(seconds, 0).UTC(), nil
+}
+
+// `time.parse(str, layout?)` parses a time written in the layout, RFC 3339 by
+// default. Times without a time zone are in UTC
+func time__parse(_ *runtime.Runtime, args []interface{}) (interface{}, error) {
+	str, ok := args[0].(string)
+	if !ok {
+		return nil, errors.New(fmt.Sprintf("`time.parse` expects a string, but got %v", args[0]))
+	}
+	layout, err := layoutArg("time.parse", args, 1)
+	if err != nil {
+		return nil, err
+	}
+
+	t, err := time.Parse(layout, str)
+	if err != nil {
+		return nil, errors.New(fmt.Sprintf("`time.parse`: %v", err))
+	}
+	return t, nil
+}
+
+// `time.since(t)` the duration from `t` until now
+func time__since(r *runtime.Runtime, args []interface{}) (interface{}, error) {
+	t, ok := args[0].(time.Time)
+	if !ok {
+		return nil, errors.New(fmt.Sprintf("`time.since` expects a time, but got %v", args[0]))
+	}
+	return r.Clock.Now().Sub(t), nil
+}
+
+// `time.sleep(duration)` where duration is a duration or a number of seconds.
+// Returns an error when the script is cancelled while sleeping
+func time__sleep(r *runtime.Runtime, args []interface{}) (interface{}, error) {
+	var duration time.Duration
+	switch args[0].(type) {
+	case time.Duration:
+		duration = args[0].(time.Duration)
+	case int64, float64:
+		seconds, _ := number("time.sleep", args[0])
+		duration = time.Duration(seconds * float64(time.Second))
+	default:
+		return nil, errors.New(fmt.Sprintf("`time.sleep` expects a duration or a number of seconds, but got %v", args[0]))
+	}
+	if duration < 0 {
+		return nil, errors.New(fmt.Sprintf("`time.sleep` got a negative duration (%v)", duration))
+	}
+
+	return nil, r.Clock.Sleep(r.Context, duration)
+}
+
+// `time.duration(str)` parses a duration such as "1h30m" or "250ms"
+func time__duration(_ *runtime.Runtime, args []interface{}) (interface{}, error) {
+	str, ok := args[0].(string)
+	if !ok {
+		return nil, errors.New(fmt.Sprintf("`time.duration` expects a string, but got %v", args[0]))
+	}
+	duration, err := time.ParseDuration(str)
+	if err != nil {
+		return nil, errors.New(fmt.Sprintf("`time.duration`: %v", err))
+	}
+	return duration, nil
+}
+
+// A function that makes a duration of a number of units, e.g. `time.seconds(1.5)`
+func durationFunc(name string, unit time.Duration) runtime.NativeFunction {
+	return func(_ *runtime.Runtime, args []interface{}) (interface{}, error) {
+		switch args[0].(type) {
+		case int64:
+			return time.Duration(args[0].(int64)) * unit, nil
+		case float64:
+			return time.Duration(math.Round(args[0].(float64) * float64(unit))), nil
+		default:
+			return nil, errors.New(fmt.Sprintf("`time.%s` expects a number, but got %v", name, args[0]))
+		}
+	}
+}
+
+// time.unix() seconds since January 1, 1970 UTC
+func time__time_unix(_ *runtime.Runtime, args []interface{}) (interface{}, error) {
+	if len(args) != 1 {
+		return nil, errors.New("`time.unix` expects no arguments")
+	}
+	return args[0].(time.Time).Unix(), nil
+}
+
+// time.unix_milli() milliseconds since January 1, 1970 UTC
+func time__time_unix_milli(_ *runtime.Runtime, args []interface{}) (interface{}, error) {
+	if len(args) != 1 {
+		return nil, errors.New("`time.unix_milli` expects no arguments")
+	}
+	return args[0].(time.Time).UnixMilli(), nil
+}
+
+// time.format(layout?) writes the time in the layout, RFC 3339 by default
+func time__time_format(_ *runtime.Runtime, args []interface{}) (interface{}, error) {
+	if len(args) != 1 && len(args) != 2 {
+		return nil, errors.New("`time.format` expects 0 or 1 arguments")
+	}
+	layout, err := layoutArg("time.format", args, 1)
+	if err != nil {
+		return nil, err
+	}
+	return args[0].(time.Time).Format(layout), nil
+}
+
+// time.add(duration)
+func time__time_add(_ *runtime.Runtime, args []interface{}) (interface{}, error) {
+	if len(args) != 2 {
+		return nil, errors.New("`time.add` expects 1 argument")
+	}
+	duration, ok := args[1].(time.Duration)
+	if !ok {
+		return nil, errors.New(fmt.Sprintf("`time.add` expects a duration, but got %v", args[1]))
+	}
+	return args[0].(time.Time).Add(duration), nil
+}
+
+// time.sub(other) the duration from `other` until this time
+func time__time_sub(_ *runtime.Runtime, args []interface{}) (interface{}, error) {
+	if len(args) != 2 {
+		return nil, errors.New("`time.sub` expects 1 argument")
+	}
+	other, ok := args[1].(time.Time)
+	if !ok {
+		return nil, errors.New(fmt.Sprintf("`time.sub` expects a time, but got %v", args[1]))
+	}
+	return args[0].(time.Time).Sub(other), nil
+}
+
+// duration.mul(n) the duration multiplied by a number
+func time__duration_mul(_ *runtime.Runtime, args []interface{}) (interface{}, error) {
+	if len(args) != 2 {
+		return nil, errors.New("`duration.mul` expects 1 argument")
+	}
+	duration := args[0].(time.Duration)
+	switch args[1].(type) {
+	case int64:
+		return duration * time.Duration(args[1].(int64)), nil
+	case float64:
+		return time.Duration(math.Round(float64(duration) * args[1].(float64))), nil
+	default:
+		return nil, errors.New(fmt.Sprintf("`duration.mul` expects a number, but got %v", args[1]))
+	}
+}
+
+// A method without arguments that returns part of a time
+func timeField(name string, fn func(time.Time) interface{}) runtime.NativeFunction {
+	return func(_ *runtime.Runtime, args []interface{}) (interface{}, error) {
+		if len(args) != 1 {
+			return nil, errors.New(fmt.Sprintf("`time.%s` expects no arguments", name))
+		}
+		return fn(args[0].(time.Time)), nil
+	}
+}
+
+// A method that compares a time with another
+func timeComparison(name string, fn func(time.Time, time.Time) bool) runtime.NativeFunction {
+	return func(_ *runtime.Runtime, args []interface{}) (interface{}, error) {
+		if len(args) != 2 {
+			return nil, errors.New(fmt.Sprintf("`time.%s` expects 1 argument", name))
+		}
+		other, ok := args[1].(time.Time)
+		if !ok {
+			return nil, errors.New(fmt.Sprintf("`time.%s` expects a time, but got %v", name, args[1]))
+		}
+		return fn(args[0].(time.Time), other), nil
+	}
+}
+
+// A method without arguments that converts a duration
+func durationField(name string, fn func(time.Duration) interface{}) runtime.NativeFunction {
+	return func(_ *runtime.Runtime, args []interface{}) (interface{}, error) {
+		if len(args) != 1 {
+			return nil, errors.New(fmt.Sprintf("`duration.%s` expects no arguments", name))
+		}
+		return fn(args[0].(time.Duration)), nil
+	}
+}
+
+// A method that combines a duration with another
+func durationArithmetic(name string, fn func(time.Duration, time.Duration) time.Duration) runtime.NativeFunction {
+	return func(_ *runtime.Runtime, args []interface{}) (interface{}, error) {
+		if len(args) != 2 {
+			return nil, errors.New(fmt.Sprintf("`duration.%s` expects 1 argument", name))
+		}
+		other, ok := args[1].(time.Duration)
+		if !ok {
+			return nil, errors.New(fmt.Sprintf("`duration.%s` expects a duration, but got %v", name, args[1]))
+		}
+		return fn(args[0].(time.Duration), other), nil
+	}
+}
+
+// The optional layout argument at index `i`, RFC 3339 if it isn't given
+func layoutArg(method string, args []interface{}, i int) (string, error) {
+	if len(args) <= i || args[i] == nil {
+		return time.RFC3339, nil
+	}
+	layout, ok := args[i].(string)
+	if !ok {
+		return "", errors.New(fmt.Sprintf("`%s` expects a string layout, but got %v", method, args[i]))
+	}
+	return layout, nil
+}